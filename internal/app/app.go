@@ -1,48 +1,183 @@
 package app
 
 import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"notes-app/internal/cache"
+	"notes-app/internal/common"
 	"notes-app/internal/index"
+	"notes-app/internal/logger"
 	"notes-app/internal/note"
 	"notes-app/internal/storage"
 )
 
+var log = logger.For("app")
+
 // NotesApp represents the main application
 type NotesApp struct {
-	storage *storage.FileSystemStorage
-	index   *index.Index
+	storage      storage.Storage
+	index        *index.Index
+	contentCache *cache.LRU[string, cachedContent]
+
+	problemsMu sync.RWMutex
+	problems   []storage.LoadProblem
 }
 
-// NewNotesApp creates a new notes application
+// contentCacheSize is the number of note contents kept in memory
+const contentCacheSize = 64
+
+// cachedContent is a note's content together with the file state it was read at
+type cachedContent struct {
+	modTime time.Time
+	size    int64
+	content string
+}
+
+// NewNotesApp creates a new notes application backed by the filesystem
 func NewNotesApp(rootPath string) *NotesApp {
+	return NewNotesAppWithStorage(storage.NewFileSystemStorage(rootPath))
+}
+
+// NewNotesAppWithStorage creates a new notes application using the given storage backend
+func NewNotesAppWithStorage(s storage.Storage) *NotesApp {
 	return &NotesApp{
-		storage: storage.NewFileSystemStorage(rootPath),
-		index:   index.NewIndex(),
+		storage:      s,
+		index:        index.NewIndex(),
+		contentCache: cache.NewLRU[string, cachedContent](contentCacheSize),
+	}
+}
+
+// Open creates an application for the given storage and initializes it.
+// An empty default extension keeps the one from the vault settings.
+func Open(s storage.Storage, defaultExtension string) (*NotesApp, error) {
+	app := NewNotesAppWithStorage(s)
+	if defaultExtension != "" {
+		app.SetDefaultExtension(defaultExtension)
+	}
+	if err := app.Initialize(); err != nil {
+		app.Close()
+		return nil, err
+	}
+	return app, nil
+}
+
+// Close releases the storage backend, e.g. the SQLite database
+func (app *NotesApp) Close() error {
+	if closer, ok := app.storage.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// RootPath returns where the vault is stored
+func (app *NotesApp) RootPath() string {
+	return app.storage.GetRootPath()
+}
+
+// SetDefaultExtension sets the extension used for new notes.
+// It must be called before Initialize and only affects filesystem vaults.
+func (app *NotesApp) SetDefaultExtension(ext string) {
+	if fs, ok := app.storage.(*storage.FileSystemStorage); ok {
+		fs.SetDefaultExtension(ext)
 	}
 }
 
 // Initialize initializes the application
 func (app *NotesApp) Initialize() error {
+	return app.InitializeContext(context.Background())
+}
+
+// InitializeContext initializes the application, stopping early if ctx is cancelled
+func (app *NotesApp) InitializeContext(ctx context.Context) error {
 	if err := app.storage.Initialize(); err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
-	return app.RefreshIndex()
+	if file := app.searchIndexFile(); file != "" {
+		terms, err := index.LoadTermIndex(file)
+		if err != nil {
+			log.Info("rebuilding search index", "err", err)
+		}
+		app.index.SetTermIndex(terms)
+	}
+
+	return app.RefreshIndexContext(ctx)
 }
 
 // RefreshIndex refreshes the note index
 func (app *NotesApp) RefreshIndex() error {
-	notes, err := app.storage.GetAllNotes()
+	return app.RefreshIndexContext(context.Background())
+}
+
+// RefreshIndexContext refreshes the note index, stopping early if ctx is cancelled.
+// The previous index is kept if loading fails.
+func (app *NotesApp) RefreshIndexContext(ctx context.Context) error {
+	notes, problems, err := app.storage.GetAllNotes(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to load notes: %w", err)
 	}
 
+	app.problemsMu.Lock()
+	app.problems = problems
+	app.problemsMu.Unlock()
+
+	terms := app.index.Terms()
+	if err := terms.Sync(ctx, notes, app.readContent); err != nil {
+		return fmt.Errorf("failed to update search index: %w", err)
+	}
+	if err := terms.Save(); err != nil {
+		log.Warn("failed to save search index", "err", err)
+	}
+
 	app.index.RebuildIndex(notes)
 	return nil
 }
 
+// Problems returns the notes that failed to load during the last refresh
+func (app *NotesApp) Problems() []storage.LoadProblem {
+	app.problemsMu.RLock()
+	defer app.problemsMu.RUnlock()
+
+	return append([]storage.LoadProblem(nil), app.problems...)
+}
+
+// RepairProblem resets the broken metadata of a note that failed to load
+func (app *NotesApp) RepairProblem(problem storage.LoadProblem) error {
+	fixer, ok := app.storage.(storage.ProblemFixer)
+	if !ok {
+		return fmt.Errorf("repairing notes is %w", ErrUnsupported)
+	}
+
+	if err := fixer.RepairNote(problem); err != nil {
+		return err
+	}
+
+	return app.RefreshIndex()
+}
+
+// QuarantineProblem moves a note that failed to load out of the vault
+func (app *NotesApp) QuarantineProblem(problem storage.LoadProblem) error {
+	fixer, ok := app.storage.(storage.ProblemFixer)
+	if !ok {
+		return fmt.Errorf("quarantining notes is %w", ErrUnsupported)
+	}
+
+	if err := fixer.QuarantineNote(problem); err != nil {
+		return err
+	}
+
+	return app.RefreshIndex()
+}
+
 // NoteExists checks if a note with the given name already exists
 func (app *NotesApp) NoteExists(name string) bool {
 	for _, note := range app.index.GetAllNotes() {
@@ -55,8 +190,11 @@ func (app *NotesApp) NoteExists(name string) bool {
 
 // CreateNote creates a new note
 func (app *NotesApp) CreateNote(name, content string) error {
+	if err := storage.ValidateNoteName(name); err != nil {
+		return err
+	}
 	if app.NoteExists(name) {
-		return fmt.Errorf("note with name '%s' already exists", name)
+		return fmt.Errorf("%w: %s", storage.ErrAlreadyExists, name)
 	}
 
 	_, err := app.storage.CreateNote(name, content)
@@ -88,7 +226,7 @@ func (app *NotesApp) UpdateNoteTags(notePath string, tags []string) error {
 
 	note.Metadata.Tags = tags
 
-	if err := note.Save(); err != nil {
+	if err := app.saveNote(note); err != nil {
 		return err
 	}
 
@@ -102,26 +240,9 @@ func (app *NotesApp) AddTagsToNote(notePath string, newTags []string) error {
 		return err
 	}
 
-	// Add new tags without duplicates
-	tagSet := make(map[string]bool)
-	for _, tag := range note.Metadata.Tags {
-		tagSet[tag] = true
-	}
+	addTags(note.Metadata, newTags)
 
-	for _, tag := range newTags {
-		tag = strings.TrimSpace(tag)
-		if tag != "" {
-			tagSet[tag] = true
-		}
-	}
-
-	// Convert back to slice
-	note.Metadata.Tags = make([]string, 0, len(tagSet))
-	for tag := range tagSet {
-		note.Metadata.Tags = append(note.Metadata.Tags, tag)
-	}
-
-	if err := note.Save(); err != nil {
+	if err := app.saveNote(note); err != nil {
 		return err
 	}
 
@@ -135,37 +256,39 @@ func (app *NotesApp) RemoveTagsFromNote(notePath string, tagsToRemove []string)
 		return err
 	}
 
-	// Create set of tags to remove
-	removeSet := make(map[string]bool)
-	for _, tag := range tagsToRemove {
-		removeSet[strings.TrimSpace(tag)] = true
+	removeTags(note.Metadata, tagsToRemove)
+
+	if err := app.saveNote(note); err != nil {
+		return err
 	}
 
-	// Filter out tags to remove
-	var newTags []string
-	for _, tag := range note.Metadata.Tags {
-		if !removeSet[tag] {
-			newTags = append(newTags, tag)
-		}
+	return app.RefreshIndex()
+}
+
+// UpdateNoteMetadata applies changes to a note's metadata and saves it
+func (app *NotesApp) UpdateNoteMetadata(notePath string, update func(meta *note.Metadata)) error {
+	note, err := app.storage.GetNote(notePath)
+	if err != nil {
+		return err
 	}
 
-	note.Metadata.Tags = newTags
+	update(note.Metadata)
 
-	if err := note.Save(); err != nil {
+	if err := app.saveNote(note); err != nil {
 		return err
 	}
 
 	return app.RefreshIndex()
 }
 
-// ShowNoteTags displays tags for a specific note
+// ShowNoteTags logs the tags of a specific note
 func (app *NotesApp) ShowNoteTags(notePath string) error {
 	note, err := app.storage.GetNote(notePath)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Tags for '%s': %v\n", note.Name, note.Metadata.Tags)
+	log.Info("note tags", "note", note.Name, "tags", note.Metadata.Tags)
 	return nil
 }
 
@@ -179,7 +302,7 @@ func (app *NotesApp) GetAllTags() []string {
 	return app.index.GetAllTags()
 }
 
-// GetStats prints application statistics
+// GetStats logs application statistics
 func (app *NotesApp) GetStats() {
 	app.index.PrintStats()
 }
@@ -191,13 +314,30 @@ func (app *NotesApp) DeleteNote(notePath string) error {
 		return err
 	}
 
-	if err := note.Delete(); err != nil {
+	if err := app.storage.DeleteNote(note.Path); err != nil {
 		return err
 	}
+	app.contentCache.Remove(note.Path)
 
 	return app.RefreshIndex()
 }
 
+// MigrateMetadataFormat converts all notes in the vault to the given metadata layout
+func (app *NotesApp) MigrateMetadataFormat(format note.MetadataFormat) (int, error) {
+	fs, ok := app.storage.(*storage.FileSystemStorage)
+	if !ok {
+		return 0, fmt.Errorf("metadata layouts only apply to filesystem vaults: %w", ErrUnsupported)
+	}
+
+	converted, err := fs.MigrateMetadataFormat(format)
+	if converted == 0 {
+		return 0, err
+	}
+
+	// Notes were converted even if cleaning up afterwards failed
+	return converted, errors.Join(err, app.RefreshIndex())
+}
+
 // GetNote retrieves a specific note
 func (app *NotesApp) GetNote(notePath string) (*note.Note, error) {
 	return app.storage.GetNote(notePath)
@@ -212,9 +352,98 @@ func (app *NotesApp) UpdateNoteContent(notePath, content string) error {
 
 	note.Content = content
 
-	if err := note.Save(); err != nil {
+	if err := app.saveNote(note); err != nil {
 		return err
 	}
 
 	return app.RefreshIndex()
 }
+
+// RenameNote renames a note
+func (app *NotesApp) RenameNote(notePath, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if err := storage.ValidateNoteName(newName); err != nil {
+		return err
+	}
+
+	current, err := app.storage.GetNote(notePath)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(current.Name, newName) && app.NoteExists(newName) {
+		return fmt.Errorf("%w: %s", storage.ErrAlreadyExists, newName)
+	}
+
+	if _, err := app.storage.RenameNote(notePath, newName); err != nil {
+		return err
+	}
+	app.contentCache.Remove(current.Path)
+
+	return app.RefreshIndex()
+}
+
+// CopyTo copies every note into another storage backend and returns
+// the notes it skipped, see storage.Copy
+func (app *NotesApp) CopyTo(dst storage.Storage) (int, []storage.LoadProblem, error) {
+	return storage.Copy(context.Background(), app.storage, dst)
+}
+
+// GetContent returns a note's content, loading it from storage on demand.
+// Recently used contents are cached until the note changes.
+func (app *NotesApp) GetContent(n *note.Note) (string, error) {
+	if n.ContentLoaded {
+		return n.Content, nil
+	}
+
+	if cached, ok := app.contentCache.Get(n.Path); ok &&
+		cached.size == n.Size && cached.modTime.Equal(n.ModTime) {
+		return cached.content, nil
+	}
+
+	content, err := app.readContent(n)
+	if err != nil {
+		return "", err
+	}
+
+	app.contentCache.Put(n.Path, cachedContent{
+		modTime: n.ModTime,
+		size:    n.Size,
+		content: content,
+	})
+	return content, nil
+}
+
+// readContent loads a note's content without keeping it on the note
+func (app *NotesApp) readContent(n *note.Note) (string, error) {
+	if n.ContentLoaded {
+		return n.Content, nil
+	}
+
+	loaded := n.Clone()
+	if err := app.storage.LoadContent(loaded); err != nil {
+		return "", err
+	}
+	return loaded.Content, nil
+}
+
+// searchIndexFile returns where the search index of the vault is persisted,
+// or an empty string for storage that only lives in memory
+func (app *NotesApp) searchIndexFile() string {
+	root := app.storage.GetRootPath()
+	if root == storage.MemoryRootPath {
+		return ""
+	}
+
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	sum := sha1.Sum([]byte(root))
+	return filepath.Join(common.GetStateDir(), "index", hex.EncodeToString(sum[:])+".gob")
+}
+
+// saveNote marks a note as updated and writes it to storage
+func (app *NotesApp) saveNote(n *note.Note) error {
+	n.Metadata.Touch(time.Now())
+	app.contentCache.Remove(n.Path)
+	return app.storage.SaveNote(n)
+}
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"notes-app/internal/storage"
+)
+
+// newMemoryApp opens an app on an empty in-memory vault
+func newMemoryApp(t *testing.T) *NotesApp {
+	t.Helper()
+	a, err := Open(storage.NewMemoryStorage(""), "")
+	if err != nil {
+		t.Fatalf("failed to open memory vault: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+	return a
+}
+
+// noteNames returns the names of the notes in the index, sorted
+func noteNames(a *NotesApp) []string {
+	var names []string
+	for _, n := range a.ListAllNotes() {
+		names = append(names, n.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestNotesAppMemoryStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(a *NotesApp) error
+		wantErr error
+		want    []string
+	}{
+		{
+			name:   "create",
+			change: func(a *NotesApp) error { return a.CreateNote("todo", "buy milk") },
+			want:   []string{"seed", "todo"},
+		},
+		{
+			name:    "create existing name in another case",
+			change:  func(a *NotesApp) error { return a.CreateNote("SEED", "") },
+			wantErr: storage.ErrAlreadyExists,
+			want:    []string{"seed"},
+		},
+		{
+			name:   "rename",
+			change: func(a *NotesApp) error { return a.RenameNote("seed", "renamed") },
+			want:   []string{"renamed"},
+		},
+		{
+			name: "rename onto existing note",
+			change: func(a *NotesApp) error {
+				if err := a.CreateNote("other", ""); err != nil {
+					return err
+				}
+				return a.RenameNote("seed", "other")
+			},
+			wantErr: storage.ErrAlreadyExists,
+			want:    []string{"other", "seed"},
+		},
+		{
+			name:   "delete",
+			change: func(a *NotesApp) error { return a.DeleteNote("seed") },
+			want:   nil,
+		},
+		{
+			name: "delete missing note",
+			change: func(a *NotesApp) error {
+				return a.DeleteNote("missing")
+			},
+			wantErr: storage.ErrNotFound,
+			want:    []string{"seed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newMemoryApp(t)
+			if err := a.CreateNote("seed", "first note"); err != nil {
+				t.Fatalf("failed to create seed note: %v", err)
+			}
+
+			err := tt.change(a)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got := noteNames(a); !slices.Equal(got, tt.want) {
+				t.Errorf("notes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotesAppTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		add    []string
+		remove []string
+		want   []string
+	}{
+		{name: "add", add: []string{"work", "urgent"}, want: []string{"work", "urgent"}},
+		{name: "add keeps tags once", add: []string{"work", "work"}, want: []string{"work"}},
+		{name: "remove", add: []string{"work", "urgent"}, remove: []string{"work"}, want: []string{"urgent"}},
+		{name: "remove missing tag", add: []string{"work"}, remove: []string{"home"}, want: []string{"work"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newMemoryApp(t)
+			if err := a.CreateNote("todo", ""); err != nil {
+				t.Fatalf("failed to create note: %v", err)
+			}
+			if err := a.AddTagsToNote("todo", tt.add); err != nil {
+				t.Fatalf("failed to add tags: %v", err)
+			}
+			if tt.remove != nil {
+				if err := a.RemoveTagsFromNote("todo", tt.remove); err != nil {
+					t.Fatalf("failed to remove tags: %v", err)
+				}
+			}
+
+			n, err := a.GetNote("todo")
+			if err != nil {
+				t.Fatalf("failed to get note: %v", err)
+			}
+			if !slices.Equal(n.Metadata.Tags, tt.want) {
+				t.Errorf("tags = %v, want %v", n.Metadata.Tags, tt.want)
+			}
+			if got := len(a.SearchNotes(tt.want[0], "tag")); got != 1 {
+				t.Errorf("notes tagged %q = %d, want 1", tt.want[0], got)
+			}
+		})
+	}
+}
+
+func TestMemoryStorageSeed(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"todo.md":         "buy milk",
+		"work/meeting.md": "agenda",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, err := Open(storage.NewMemoryStorage(dir), "")
+	if err != nil {
+		t.Fatalf("failed to open seeded vault: %v", err)
+	}
+	defer a.Close()
+
+	if got, want := noteNames(a), []string{"meeting", "todo"}; !slices.Equal(got, want) {
+		t.Fatalf("notes = %v, want %v", got, want)
+	}
+	n, err := a.GetNote("work/meeting")
+	if err != nil {
+		t.Fatalf("failed to get seeded note: %v", err)
+	}
+	if content, _ := a.GetContent(n); content != "agenda" {
+		t.Errorf("content = %q, want %q", content, "agenda")
+	}
+
+	// Changes stay in memory and never reach the fixture directory
+	if err := a.CreateNote("new", "text"); err != nil {
+		t.Fatalf("failed to create note: %v", err)
+	}
+	if err := a.DeleteNote("todo"); err != nil {
+		t.Fatalf("failed to delete note: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"todo.md", "work"}; !slices.Equal(names, want) {
+		t.Errorf("fixture directory = %v, want %v", names, want)
+	}
+}
+
+func TestNotesAppSearchContent(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "milk", want: []string{"todo"}},
+		{query: "mil", want: []string{"todo"}},
+		{query: "ilk", want: []string{"todo"}},
+		{query: "buy ilk", want: []string{"todo"}},
+		{query: "genda", want: []string{"meeting"}},
+		{query: "eet", want: []string{"meeting"}},
+		{query: "bread", want: nil},
+	}
+
+	a := newMemoryApp(t)
+	if err := a.CreateNote("todo", "buy milk"); err != nil {
+		t.Fatalf("failed to create note: %v", err)
+	}
+	if err := a.CreateNote("meeting", "agenda"); err != nil {
+		t.Fatalf("failed to create note: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got []string
+			for _, n := range a.SearchNotes(tt.query, "content") {
+				got = append(got, n.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("search %q = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
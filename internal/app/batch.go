@@ -0,0 +1,204 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"notes-app/internal/common"
+	"notes-app/internal/note"
+	"notes-app/internal/storage"
+)
+
+// mergeSeparator is placed between the contents of merged notes
+const mergeSeparator = "\n\n---\n\n"
+
+// The batch methods below apply one change to many notes and refresh the
+// index once at the end. They carry on past notes that fail and return the
+// number of notes changed together with the joined errors.
+
+// DeleteNotes removes notes and their metadata
+func (app *NotesApp) DeleteNotes(paths []string) (int, error) {
+	return app.batch(paths, func(n *note.Note) error {
+		if err := app.storage.DeleteNote(n.Path); err != nil {
+			return err
+		}
+		app.contentCache.Remove(n.Path)
+		return nil
+	})
+}
+
+// AddTagsToNotes adds tags to notes without duplicates
+func (app *NotesApp) AddTagsToNotes(paths, tags []string) (int, error) {
+	return app.batch(paths, func(n *note.Note) error {
+		addTags(n.Metadata, tags)
+		return app.saveNote(n)
+	})
+}
+
+// RemoveTagsFromNotes removes tags from notes
+func (app *NotesApp) RemoveTagsFromNotes(paths, tags []string) (int, error) {
+	return app.batch(paths, func(n *note.Note) error {
+		removeTags(n.Metadata, tags)
+		return app.saveNote(n)
+	})
+}
+
+// MoveNotes moves notes into a folder of the vault, "" being its top
+func (app *NotesApp) MoveNotes(paths []string, folder string) (int, error) {
+	folder = strings.Trim(strings.TrimSpace(folder), "/")
+	if folder != "" {
+		if err := storage.ValidateNoteName(folder); err != nil {
+			return 0, err
+		}
+	}
+
+	return app.batch(paths, func(n *note.Note) error {
+		if _, err := app.storage.MoveNote(n.Path, folder); err != nil {
+			return err
+		}
+		app.contentCache.Remove(n.Path)
+		return nil
+	})
+}
+
+// ExportNotes copies notes with their metadata into a folder outside the
+// vault, which becomes a filesystem vault of its own. Notes keep their
+// subfolders and existing files are never overwritten.
+func (app *NotesApp) ExportNotes(paths []string, dir string) (int, error) {
+	dir, err := filepath.Abs(common.ExpandHome(strings.TrimSpace(dir)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve export folder: %w", err)
+	}
+	if root, err := filepath.Abs(app.storage.GetRootPath()); err == nil && root == dir {
+		return 0, fmt.Errorf("cannot export notes into their own vault")
+	}
+
+	dst := storage.NewFileSystemStorage(dir)
+	if err := dst.Initialize(); err != nil {
+		return 0, fmt.Errorf("failed to open export folder: %w", err)
+	}
+
+	exported := 0
+	var errs []error
+	for _, path := range paths {
+		n, err := app.storage.GetNote(path)
+		if err == nil {
+			// Exported files keep their extension
+			name := storage.RelativeName(app.storage, n) + filepath.Ext(n.Path)
+			err = storage.CopyNote(app.storage, dst, n, name)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		exported++
+	}
+
+	log.Info("exported notes", "count", exported, "dir", dir)
+	return exported, errors.Join(errs...)
+}
+
+// MergeNotes appends the contents of the other notes to the first one,
+// joins their tags and deletes the others. Nothing is deleted unless the
+// merged note was saved.
+func (app *NotesApp) MergeNotes(paths []string) (*note.Note, error) {
+	if len(paths) < 2 {
+		return nil, fmt.Errorf("%w: at least two are needed to merge", ErrTooFewNotes)
+	}
+
+	target, err := app.storage.GetNote(paths[0])
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []string{strings.TrimRight(target.Content, "\n")}
+	var others []*note.Note
+	for _, path := range paths[1:] {
+		n, err := app.storage.GetNote(path)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, strings.TrimRight(n.Content, "\n"))
+		addTags(target.Metadata, n.Metadata.Tags)
+		others = append(others, n)
+	}
+
+	target.Content = strings.Join(parts, mergeSeparator) + "\n"
+	if err := app.saveNote(target); err != nil {
+		return nil, fmt.Errorf("failed to save merged note: %w", err)
+	}
+
+	var errs []error
+	for _, n := range others {
+		if err := app.storage.DeleteNote(n.Path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete merged note %s: %w", n.Name, err))
+			continue
+		}
+		app.contentCache.Remove(n.Path)
+	}
+
+	log.Info("merged notes", "into", target.Path, "count", len(others))
+	if err := app.RefreshIndex(); err != nil {
+		errs = append(errs, err)
+	}
+	return target, errors.Join(errs...)
+}
+
+// batch loads each note, applies change to it and refreshes the index once
+func (app *NotesApp) batch(paths []string, change func(n *note.Note) error) (int, error) {
+	changed := 0
+	var errs []error
+	for _, path := range paths {
+		n, err := app.storage.GetNote(path)
+		if err == nil {
+			err = change(n)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+		changed++
+	}
+
+	if changed > 0 {
+		if err := app.RefreshIndex(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return changed, errors.Join(errs...)
+}
+
+// addTags adds tags to metadata, keeping the existing order and skipping
+// empty and duplicate tags
+func addTags(meta *note.Metadata, tags []string) {
+	seen := make(map[string]bool, len(meta.Tags))
+	for _, tag := range meta.Tags {
+		seen[tag] = true
+	}
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			meta.Tags = append(meta.Tags, tag)
+		}
+	}
+}
+
+// removeTags removes tags from metadata
+func removeTags(meta *note.Metadata, tags []string) {
+	remove := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		remove[strings.TrimSpace(tag)] = true
+	}
+
+	var kept []string
+	for _, tag := range meta.Tags {
+		if !remove[tag] {
+			kept = append(kept, tag)
+		}
+	}
+	meta.Tags = kept
+}
@@ -0,0 +1,11 @@
+package app
+
+import "errors"
+
+// Errors wrapped by NotesApp in addition to those of the storage package
+var (
+	// ErrUnsupported means the storage backend of the vault cannot do the operation
+	ErrUnsupported = errors.New("not supported by this storage backend")
+	// ErrTooFewNotes means an operation on several notes was given too few
+	ErrTooFewNotes = errors.New("not enough notes")
+)
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"sort"
+	"strings"
+
+	"notes-app/internal/note"
+)
+
+// VaultMatch is a note found while searching several vaults
+type VaultMatch struct {
+	Vault string
+	Note  *note.Note
+}
+
+// SearchVaults searches the given vaults in order. Queries starting with
+// '#' search tags, anything else searches names and content.
+func SearchVaults(names []string, apps map[string]*NotesApp, query string) []VaultMatch {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+
+	searchType, term := "content", query
+	if strings.HasPrefix(query, "#") {
+		searchType, term = "tag", strings.TrimPrefix(query, "#")
+	}
+
+	var matches []VaultMatch
+	for _, name := range names {
+		app, ok := apps[name]
+		if !ok {
+			continue
+		}
+
+		notes := app.SearchNotes(term, searchType)
+		sort.Slice(notes, func(i, j int) bool {
+			return strings.ToLower(notes[i].DisplayTitle()) < strings.ToLower(notes[j].DisplayTitle())
+		})
+		for _, n := range notes {
+			matches = append(matches, VaultMatch{Vault: name, Note: n})
+		}
+	}
+	return matches
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 )
 
 func GetDefaultNotesPath() string {
@@ -21,3 +22,65 @@ func GetDefaultNotesPath() string {
 		return filepath.Join(homeDir, "Notes")
 	}
 }
+
+// GetStateDir returns the directory for persistent application state
+// such as session settings, logs and drafts
+func GetStateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "notes-app")
+	}
+
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		if dir, err := os.UserConfigDir(); err == nil {
+			return filepath.Join(dir, "notes-app", "state")
+		}
+	default: // linux and others
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, ".local", "state", "notes-app")
+		}
+	}
+
+	return filepath.Join(os.TempDir(), "notes-app")
+}
+
+// GetConfigDir returns the directory holding the configuration file
+func GetConfigDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "notes-app")
+	}
+
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "notes-app")
+	}
+
+	return "./.notes-app"
+}
+
+// ExpandHome replaces a leading ~ with the user's home directory
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+}
+
+// CollapseHome replaces the user's home directory at the start of path with ~
+func CollapseHome(path string) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil || homeDir == "" {
+		return path
+	}
+	if path == homeDir {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, homeDir+string(filepath.Separator)); ok {
+		return filepath.Join("~", rest)
+	}
+	return path
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"notes-app/internal/common"
+	"notes-app/internal/logger"
+	"notes-app/internal/note"
+	"notes-app/internal/storage"
+)
+
+// Config holds the application configuration
+type Config struct {
+	VaultPath        string              `toml:"vault_path"`
+	Backend          string              `toml:"backend"`
+	SQLitePath       string              `toml:"sqlite_path"`
+	DefaultExtension string              `toml:"default_extension"`
+	Editor           string              `toml:"editor"`
+	SortOrder        string              `toml:"sort_order"`
+	SplitRatio       float64             `toml:"split_ratio"`
+	Theme            string              `toml:"theme"`
+	DateFormat       string              `toml:"date_format"`
+	DateTimeFormat   string              `toml:"datetime_format"`
+	LogLevel         string              `toml:"log_level"`
+	Keybindings      map[string][]string `toml:"keybindings"`
+	DefaultVault     string              `toml:"default_vault,omitempty"`
+	Vaults           map[string]Vault    `toml:"vaults,omitempty"`
+
+	// path is the file the configuration was loaded from
+	path string
+	// vault is the name of the vault in use
+	vault string
+	// defaultVault holds the top-level vault settings, which VaultPath and
+	// the other vault fields stop describing once another vault is used
+	defaultVault Vault
+}
+
+// DefaultSQLiteFile is the database file name used inside the vault
+// when sqlite_path is not set
+const DefaultSQLiteFile = "notes.db"
+
+// ValidationError lists every problem found in a configuration file
+type ValidationError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration in %s:\n  - %s", e.Path, strings.Join(e.Problems, "\n  - "))
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		VaultPath:      common.GetDefaultNotesPath(),
+		Backend:        storage.BackendFileSystem,
+		Editor:         defaultEditor(),
+		SortOrder:      note.DefaultSortOrder.String(),
+		SplitRatio:     0.4,
+		Theme:          "auto",
+		DateFormat:     "2006-01-02",
+		DateTimeFormat: "2006-01-02 15:04",
+		LogLevel:       "info",
+		Keybindings:    map[string][]string{},
+	}
+}
+
+// DefaultPath returns the location of the configuration file.
+// NOTESAPP_CONFIG overrides the default location.
+func DefaultPath() string {
+	if path := os.Getenv("NOTESAPP_CONFIG"); path != "" {
+		return path
+	}
+	return filepath.Join(common.GetConfigDir(), "config.toml")
+}
+
+// Load reads the configuration file at path on top of the defaults and
+// applies environment overrides. A missing file is not an error.
+// Extra checks validate settings owned by other packages, such as
+// keybinding actions and theme names.
+func Load(path string, checks ...func(*Config) []string) (*Config, error) {
+	cfg := Default()
+	cfg.path = path
+
+	meta, err := toml.DecodeFile(path, cfg)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	var problems []string
+	for _, key := range meta.Undecoded() {
+		problems = append(problems, fmt.Sprintf("unknown setting '%s'", key.String()))
+	}
+
+	// Environment variables take precedence over the file
+	if notesPath := os.Getenv("NOTES_PATH"); notesPath != "" {
+		cfg.VaultPath = notesPath
+	}
+	if os.Getenv("NOTESAPP_LOG_LEVEL") != "" || os.Getenv("NOTESAPP_DEBUG") != "" {
+		cfg.LogLevel = logger.DefaultLevels()
+	}
+	cfg.VaultPath = common.ExpandHome(cfg.VaultPath)
+	if cfg.SQLitePath == "" {
+		cfg.SQLitePath = filepath.Join(cfg.VaultPath, DefaultSQLiteFile)
+	}
+	cfg.SQLitePath = common.ExpandHome(cfg.SQLitePath)
+
+	cfg.vault = DefaultVaultName
+	cfg.defaultVault = Vault{
+		Name:             DefaultVaultName,
+		Path:             cfg.VaultPath,
+		Backend:          cfg.Backend,
+		SQLitePath:       cfg.SQLitePath,
+		DefaultExtension: cfg.DefaultExtension,
+	}
+
+	problems = append(problems, cfg.validate()...)
+	problems = append(problems, cfg.validateVaults()...)
+	for _, check := range checks {
+		problems = append(problems, check(cfg)...)
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Path: path, Problems: problems}
+	}
+
+	// NOTES_PATH points at a vault directly, so it wins over default_vault
+	if cfg.DefaultVault != "" && os.Getenv("NOTES_PATH") == "" {
+		if err := cfg.UseVault(cfg.DefaultVault); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// Path returns the file the configuration was loaded from
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Exists reports whether the configuration file exists on disk
+func (c *Config) Exists() bool {
+	_, err := os.Stat(c.path)
+	return err == nil
+}
+
+// validate checks every setting and returns a description of each problem
+func (c *Config) validate() []string {
+	var problems []string
+
+	if strings.TrimSpace(c.VaultPath) == "" {
+		problems = append(problems, "vault_path must not be empty")
+	}
+
+	// An empty default extension leaves the choice to the vault settings
+	switch ext := strings.TrimPrefix(strings.TrimSpace(c.DefaultExtension), "."); {
+	case c.DefaultExtension == "":
+	case ext == "" || strings.ContainsAny(ext, `/\.`):
+		problems = append(problems, fmt.Sprintf("default_extension '%s' is not a valid file extension", c.DefaultExtension))
+	case strings.EqualFold("."+ext, note.MetaExtension):
+		problems = append(problems, fmt.Sprintf("default_extension cannot be '%s'", note.MetaExtension))
+	}
+
+	if _, err := storage.New(c.Backend, c.VaultPath, c.SQLitePath); err != nil {
+		problems = append(problems, fmt.Sprintf("backend: %v", err))
+	}
+
+	if _, err := note.ParseSortOrder(c.SortOrder); err != nil {
+		problems = append(problems, fmt.Sprintf("sort_order: %v", err))
+	}
+
+	if !isTimeLayout(c.DateFormat) {
+		problems = append(problems, fmt.Sprintf("date_format '%s' is not a Go time layout (e.g. 2006-01-02)", c.DateFormat))
+	}
+	if !isTimeLayout(c.DateTimeFormat) {
+		problems = append(problems, fmt.Sprintf("datetime_format '%s' is not a Go time layout (e.g. 2006-01-02 15:04)", c.DateTimeFormat))
+	}
+
+	if err := logger.ValidateLevels(c.LogLevel); err != nil {
+		problems = append(problems, fmt.Sprintf("log_level: %v", err))
+	}
+
+	actions := make([]string, 0, len(c.Keybindings))
+	for action := range c.Keybindings {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	for _, action := range actions {
+		keys := c.Keybindings[action]
+		if len(keys) == 0 {
+			problems = append(problems, fmt.Sprintf("keybindings.%s must list at least one key", action))
+		}
+		for _, k := range keys {
+			if strings.TrimSpace(k) == "" {
+				problems = append(problems, fmt.Sprintf("keybindings.%s contains an empty key", action))
+			}
+		}
+	}
+
+	return problems
+}
+
+// NewStorage creates the storage backend selected by the configuration
+func (c *Config) NewStorage() (storage.Storage, error) {
+	return storage.New(c.Backend, c.VaultPath, c.SQLitePath)
+}
+
+// String renders the effective configuration as TOML
+func (c *Config) String() string {
+	var b strings.Builder
+	if err := toml.NewEncoder(&b).Encode(c); err != nil {
+		return fmt.Sprintf("# failed to encode configuration: %v\n", err)
+	}
+	return b.String()
+}
+
+// isTimeLayout reports whether the layout contains at least one time element
+func isTimeLayout(layout string) bool {
+	if strings.TrimSpace(layout) == "" {
+		return false
+	}
+	reference := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	return reference.Format(layout) != layout
+}
+
+// defaultEditor returns the editor from the environment, falling back to vi
+func defaultEditor() string {
+	if editor := os.Getenv("VISUAL"); editor != "" {
+		return editor
+	}
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor
+	}
+	return "vi"
+}
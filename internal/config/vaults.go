@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"notes-app/internal/common"
+	"notes-app/internal/storage"
+)
+
+// DefaultVaultName is the name of the vault configured by the top-level
+// vault_path, backend and sqlite_path settings
+const DefaultVaultName = "default"
+
+// Vault is a named vault from the [vaults] table. Settings that are left
+// empty fall back to the top-level ones.
+type Vault struct {
+	Name             string `toml:"-"`
+	Path             string `toml:"path"`
+	Backend          string `toml:"backend,omitempty"`
+	SQLitePath       string `toml:"sqlite_path,omitempty"`
+	DefaultExtension string `toml:"default_extension,omitempty"`
+}
+
+// NewStorage creates the storage backend of the vault
+func (v Vault) NewStorage() (storage.Storage, error) {
+	return storage.New(v.Backend, v.Path, v.SQLitePath)
+}
+
+// VaultNames returns the names of all vaults, the default vault first
+func (c *Config) VaultNames() []string {
+	names := []string{DefaultVaultName}
+	for name := range c.Vaults {
+		if name != DefaultVaultName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// Vault returns the vault with the given name with its defaults filled in
+func (c *Config) Vault(name string) (Vault, error) {
+	v, ok := c.Vaults[name]
+	if !ok {
+		if name != DefaultVaultName {
+			return Vault{}, fmt.Errorf("unknown vault '%s' (known vaults: %s)", name, strings.Join(c.VaultNames(), ", "))
+		}
+		v = c.defaultVault
+	}
+
+	v.Name = name
+	if v.Backend == "" {
+		v.Backend = c.defaultVault.Backend
+	}
+	if v.DefaultExtension == "" {
+		v.DefaultExtension = c.defaultVault.DefaultExtension
+	}
+	v.Path = common.ExpandHome(v.Path)
+	if v.SQLitePath == "" {
+		v.SQLitePath = filepath.Join(v.Path, DefaultSQLiteFile)
+	}
+	v.SQLitePath = common.ExpandHome(v.SQLitePath)
+	return v, nil
+}
+
+// CurrentVault returns the vault that is in use
+func (c *Config) CurrentVault() Vault {
+	v, err := c.Vault(c.vault)
+	if err != nil {
+		v, _ = c.Vault(DefaultVaultName)
+	}
+	return v
+}
+
+// UseVault makes the named vault the current one, so that VaultPath,
+// Backend, SQLitePath and DefaultExtension describe it
+func (c *Config) UseVault(name string) error {
+	v, err := c.Vault(name)
+	if err != nil {
+		return err
+	}
+
+	c.vault = name
+	c.VaultPath = v.Path
+	c.Backend = v.Backend
+	c.SQLitePath = v.SQLitePath
+	c.DefaultExtension = v.DefaultExtension
+	return nil
+}
+
+// validateVaults checks the vault registry
+func (c *Config) validateVaults() []string {
+	var problems []string
+
+	for _, name := range c.VaultNames()[1:] {
+		if strings.TrimSpace(name) == "" {
+			problems = append(problems, "vault names must not be empty")
+			continue
+		}
+		v, _ := c.Vault(name)
+		if strings.TrimSpace(v.Path) == "" {
+			problems = append(problems, fmt.Sprintf("vaults.%s.path must not be empty", name))
+			continue
+		}
+		if _, err := v.NewStorage(); err != nil {
+			problems = append(problems, fmt.Sprintf("vaults.%s.backend: %v", name, err))
+		}
+	}
+
+	if c.DefaultVault != "" {
+		if _, err := c.Vault(c.DefaultVault); err != nil {
+			problems = append(problems, fmt.Sprintf("default_vault: %v", err))
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,411 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"notes-app/internal/logger"
+	"notes-app/internal/note"
+	"notes-app/internal/storage"
+)
+
+var log = logger.For("doctor")
+
+// Severity tells how serious an issue is
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+// String returns the name of the severity
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// Check names the consistency check that found an issue
+type Check string
+
+const (
+	CheckOrphanedMeta    Check = "orphaned-meta"
+	CheckCaseConflict    Check = "case-conflict"
+	CheckNameConflict    Check = "name-conflict"
+	CheckInvalidMetadata Check = "invalid-metadata"
+	CheckEmptyTag        Check = "empty-tag"
+	CheckDuplicateTag    Check = "duplicate-tag"
+	CheckTempFile        Check = "temp-file"
+	CheckUnreadable      Check = "unreadable"
+)
+
+// Issue is a single problem found in the vault
+type Issue struct {
+	Severity Severity
+	Check    Check
+	Path     string
+	Message  string
+	// Fixable is true for issues that can be fixed without losing data
+	Fixable bool
+	// Fixed is true once the issue has been fixed
+	Fixed bool
+	// FixErr is set when fixing the issue failed
+	FixErr error
+
+	fix func() error
+}
+
+// Options controls what Run does with the issues it finds
+type Options struct {
+	// Fix applies the safe fixes
+	Fix bool
+	// DryRun reports what Fix would change without changing anything
+	DryRun bool
+}
+
+// Report lists the issues found in a vault
+type Report struct {
+	Root   string
+	Issues []Issue
+}
+
+// Count returns the number of issues with the given severity
+func (r *Report) Count(severity Severity) int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// Fixed returns the number of issues that were fixed
+func (r *Report) Fixed() int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Fixed {
+			count++
+		}
+	}
+	return count
+}
+
+// Fixable returns the number of issues that can be fixed automatically
+func (r *Report) Fixable() int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Fixable {
+			count++
+		}
+	}
+	return count
+}
+
+// Run checks a filesystem vault for consistency problems and, if asked,
+// fixes the safe ones. Checking never writes to the vault; fixes only ever
+// move files into the quarantine folder or rewrite tags, never delete.
+func Run(fs *storage.FileSystemStorage, opts Options) (*Report, error) {
+	root := fs.GetRootPath()
+	settings := fs.Settings()
+	report := &Report{Root: root}
+
+	var notes, metas, temps []string
+	err := filepath.WalkDir(root, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			// Record the folder or file and keep checking the rest of the vault
+			report.add(Issue{
+				Severity: Error,
+				Check:    CheckUnreadable,
+				Path:     path,
+				Message:  err.Error(),
+			})
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case settings.HasNoteExtension(path):
+			notes = append(notes, path)
+		case filepath.Ext(path) == note.MetaExtension:
+			metas = append(metas, path)
+		case isTempFile(d.Name()):
+			temps = append(temps, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk vault: %w", err)
+	}
+
+	report.checkOrphanedMeta(fs, metas, notes)
+	report.checkConflicts(root, notes)
+	report.checkMetadata(notes, settings.MetadataFormat)
+	report.checkTempFiles(fs, temps)
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Path < report.Issues[j].Path
+	})
+
+	if opts.Fix && !opts.DryRun {
+		report.fix()
+	}
+	return report, nil
+}
+
+// fix applies the fixes of all fixable issues
+func (r *Report) fix() {
+	for i := range r.Issues {
+		issue := &r.Issues[i]
+		if !issue.Fixable || issue.fix == nil {
+			continue
+		}
+		if err := issue.fix(); err != nil {
+			log.Warn("failed to fix issue", "check", issue.Check, "path", issue.Path, "err", err)
+			issue.FixErr = err
+			continue
+		}
+		log.Info("fixed issue", "check", issue.Check, "path", issue.Path)
+		issue.Fixed = true
+	}
+}
+
+// add records an issue
+func (r *Report) add(issue Issue) {
+	r.Issues = append(r.Issues, issue)
+}
+
+// checkOrphanedMeta reports sidecar files whose note no longer exists
+func (r *Report) checkOrphanedMeta(fs *storage.FileSystemStorage, metas, notes []string) {
+	owned := make(map[string]bool, len(notes))
+	for _, path := range notes {
+		owned[note.MetaPathFor(path)] = true
+	}
+
+	for _, meta := range metas {
+		if owned[meta] {
+			continue
+		}
+		r.add(Issue{
+			Severity: Warning,
+			Check:    CheckOrphanedMeta,
+			Path:     meta,
+			Message:  "metadata file without a note",
+			Fixable:  true,
+			fix: func() error {
+				_, err := fs.QuarantineFile(meta)
+				return err
+			},
+		})
+	}
+}
+
+// checkConflicts reports notes whose paths differ only by case, which
+// clash on case-insensitive filesystems, and notes in other folders or
+// with other extensions that share a name, which the app takes to be the
+// same note when creating or renaming notes
+func (r *Report) checkConflicts(root string, notes []string) {
+	rel := func(path string) string {
+		if rel, err := filepath.Rel(root, path); err == nil {
+			return rel
+		}
+		return path
+	}
+
+	byName := make(map[string][]string)
+	for _, path := range notes {
+		key := strings.ToLower(noteName(path))
+		byName[key] = append(byName[key], path)
+	}
+
+	for _, path := range notes {
+		var sameCase, sameName []string
+		for _, other := range byName[strings.ToLower(noteName(path))] {
+			switch {
+			case other == path:
+			case strings.EqualFold(rel(other), rel(path)):
+				sameCase = append(sameCase, rel(other))
+			default:
+				sameName = append(sameName, rel(other))
+			}
+		}
+
+		if len(sameCase) > 0 {
+			r.add(Issue{
+				Severity: Warning,
+				Check:    CheckCaseConflict,
+				Path:     path,
+				Message:  "differs only by case from " + strings.Join(sameCase, ", "),
+			})
+		}
+		if len(sameName) > 0 {
+			r.add(Issue{
+				Severity: Warning,
+				Check:    CheckNameConflict,
+				Path:     path,
+				Message:  "has the same name as " + strings.Join(sameName, ", ") + ", rename one of them",
+			})
+		}
+	}
+}
+
+// checkMetadata reports unreadable metadata and tags that are empty or repeated
+func (r *Report) checkMetadata(notes []string, format note.MetadataFormat) {
+	for _, path := range notes {
+		n, err := note.LoadNoteInfo(path, format)
+		if err != nil {
+			var metaErr *note.MetadataError
+			if errors.As(err, &metaErr) {
+				r.add(Issue{
+					Severity: Error,
+					Check:    CheckInvalidMetadata,
+					Path:     metaErr.Path,
+					Message:  fmt.Sprintf("%v (repair it from the problems screen)", metaErr.Err),
+				})
+			}
+			continue
+		}
+
+		empty, duplicates := 0, 0
+		seen := make(map[string]bool)
+		for _, tag := range n.Metadata.Tags {
+			tag = strings.TrimSpace(tag)
+			switch {
+			case tag == "":
+				empty++
+			case seen[tag]:
+				duplicates++
+			}
+			seen[tag] = true
+		}
+
+		// Both issues are fixed by the same rewrite, which only runs once
+		var cleaned bool
+		var cleanErr error
+		fix := func() error {
+			if !cleaned {
+				cleaned = true
+				cleanErr = cleanTags(path, format)
+			}
+			return cleanErr
+		}
+		if empty > 0 {
+			r.add(Issue{
+				Severity: Warning,
+				Check:    CheckEmptyTag,
+				Path:     path,
+				Message:  fmt.Sprintf("%d empty %s", empty, plural(empty, "tag")),
+				Fixable:  true,
+				fix:      fix,
+			})
+		}
+		if duplicates > 0 {
+			r.add(Issue{
+				Severity: Warning,
+				Check:    CheckDuplicateTag,
+				Path:     path,
+				Message:  fmt.Sprintf("%d duplicate %s", duplicates, plural(duplicates, "tag")),
+				Fixable:  true,
+				fix:      fix,
+			})
+		}
+	}
+}
+
+// checkTempFiles reports temporary and backup files left behind by editors
+func (r *Report) checkTempFiles(fs *storage.FileSystemStorage, temps []string) {
+	for _, path := range temps {
+		issue := Issue{
+			Severity: Info,
+			Check:    CheckTempFile,
+			Path:     path,
+			Message:  "stray temporary file",
+			Fixable:  true,
+			fix: func() error {
+				_, err := fs.QuarantineFile(path)
+				return err
+			},
+		}
+		if isSwapFile(filepath.Base(path)) {
+			// The editor may still be running
+			issue.Message = "editor swap file, remove it once the editor is closed"
+			issue.Fixable = false
+			issue.fix = nil
+		}
+		r.add(issue)
+	}
+}
+
+// cleanTags removes empty and duplicate tags from a note
+func cleanTags(path string, format note.MetadataFormat) error {
+	n, err := note.LoadNote(path, format)
+	if err != nil {
+		return err
+	}
+
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range n.Metadata.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	n.Metadata.Tags = tags
+
+	return n.Write()
+}
+
+// noteName returns the name the app lists a note under, its file name
+// without the extension
+func noteName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// isTempFile reports whether a file name looks like a temporary file
+func isTempFile(name string) bool {
+	return strings.HasSuffix(name, ".tmp") ||
+		strings.HasSuffix(name, "~") ||
+		isSwapFile(name)
+}
+
+// isSwapFile reports whether a file name looks like an editor swap or lock file
+func isSwapFile(name string) bool {
+	return strings.HasSuffix(name, ".swp") ||
+		strings.HasSuffix(name, ".swo") ||
+		strings.HasPrefix(name, ".#")
+}
+
+// plural returns word with an s appended unless n is one
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
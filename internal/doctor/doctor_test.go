@@ -0,0 +1,187 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"notes-app/internal/note"
+	"notes-app/internal/storage"
+)
+
+// testVault holds the files of a vault with one of every issue
+var testVault = map[string]string{
+	"Todo.md":          "todo in markdown",
+	"todo.txt":         "todo in text",
+	"work/TODO.note":   "todo at work",
+	"Plan.note":        "plan",
+	"plan.note":        "other plan",
+	"orphan.meta":      `{"schema_version":2,"tags":[]}`,
+	"draft.tmp":        "left over",
+	"todo.swp":         "swap",
+	"tagged.note":      "tagged",
+	"tagged.meta":      `{"schema_version":2,"tags":["a"," ","a","b"]}`,
+	"fine.note":        "fine",
+	"fine.meta":        `{"schema_version":2,"tags":["ok"]}`,
+	".git/HEAD":        "ignored",
+	".quarantine/x.md": "ignored",
+}
+
+// newTestVault writes testVault to a temp directory and opens it
+func newTestVault(t *testing.T) *storage.FileSystemStorage {
+	t.Helper()
+	root := t.TempDir()
+	for name, data := range testVault {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fs := storage.NewFileSystemStorage(root)
+	if err := fs.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return fs
+}
+
+// vaultFiles returns the contents of every file in the vault by relative path
+func vaultFiles(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// issueKeys returns "check path" for every issue, sorted
+func issueKeys(r *Report) []string {
+	var keys []string
+	for _, issue := range r.Issues {
+		rel, _ := filepath.Rel(r.Root, issue.Path)
+		keys = append(keys, string(issue.Check)+" "+filepath.ToSlash(rel))
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestRunFindsIssues(t *testing.T) {
+	fs := newTestVault(t)
+	before := vaultFiles(t, fs.GetRootPath())
+
+	for _, opts := range []Options{{}, {Fix: true, DryRun: true}} {
+		report, err := Run(fs, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{
+			"case-conflict Plan.note",
+			"case-conflict plan.note",
+			"duplicate-tag tagged.note",
+			"empty-tag tagged.note",
+			"name-conflict Todo.md",
+			"name-conflict todo.txt",
+			"name-conflict work/TODO.note",
+			"orphaned-meta orphan.meta",
+			"temp-file draft.tmp",
+			"temp-file todo.swp",
+		}
+		if got := issueKeys(report); !slices.Equal(got, want) {
+			t.Errorf("%+v: issues =\n%v\nwant\n%v", opts, got, want)
+		}
+		if report.Fixed() != 0 {
+			t.Errorf("%+v: %d issues fixed, want none", opts, report.Fixed())
+		}
+
+		// Neither checking nor a dry run may change the vault
+		after := vaultFiles(t, fs.GetRootPath())
+		if len(after) != len(before) {
+			t.Errorf("%+v: vault has %d files, want %d", opts, len(after), len(before))
+		}
+		for path, data := range before {
+			if after[path] != data {
+				t.Errorf("%+v: %s changed to %q", opts, path, after[path])
+			}
+		}
+	}
+}
+
+func TestRunFix(t *testing.T) {
+	fs := newTestVault(t)
+	report, err := Run(fs, Options{Fix: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixed := make(map[string]bool)
+	for _, issue := range report.Issues {
+		if issue.FixErr != nil {
+			t.Errorf("fixing %s %s failed: %v", issue.Check, issue.Path, issue.FixErr)
+		}
+		rel, _ := filepath.Rel(report.Root, issue.Path)
+		fixed[string(issue.Check)+" "+filepath.ToSlash(rel)] = issue.Fixed
+	}
+	for key, want := range map[string]bool{
+		"orphaned-meta orphan.meta": true,
+		"temp-file draft.tmp":       true,
+		"temp-file todo.swp":        false,
+		"empty-tag tagged.note":     true,
+		"duplicate-tag tagged.note": true,
+		"name-conflict Todo.md":     false,
+		"case-conflict Plan.note":   false,
+	} {
+		if fixed[key] != want {
+			t.Errorf("%s fixed = %v, want %v", key, fixed[key], want)
+		}
+	}
+
+	files := vaultFiles(t, fs.GetRootPath())
+	for _, moved := range []string{"orphan.meta", "draft.tmp"} {
+		if _, ok := files[moved]; ok {
+			t.Errorf("%s is still in the vault", moved)
+		}
+		if files[".quarantine/"+moved] != testVault[moved] {
+			t.Errorf("%s was not moved to the quarantine folder intact", moved)
+		}
+	}
+	for _, kept := range []string{"Todo.md", "todo.txt", "work/TODO.note", "Plan.note", "plan.note", "todo.swp"} {
+		if files[kept] != testVault[kept] {
+			t.Errorf("%s changed by fixing", kept)
+		}
+	}
+
+	n, err := note.LoadNote(filepath.Join(fs.GetRootPath(), "tagged.note"), fs.MetadataFormat())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b"}; !slices.Equal(n.Metadata.Tags, want) || n.Content != "tagged" {
+		t.Errorf("cleaned note = %q %v, want tags %v", n.Content, n.Metadata.Tags, want)
+	}
+
+	// A second run finds only the issues that need a person
+	again, err := Run(fs, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Fixable() != 0 {
+		t.Errorf("issues left to fix: %v", issueKeys(again))
+	}
+}
@@ -1,16 +1,22 @@
 package index
 
 import (
-	"fmt"
 	"strings"
+	"sync"
 
+	"notes-app/internal/logger"
 	"notes-app/internal/note"
 )
 
-// Index maintains an in-memory index of notes
+var log = logger.For("index")
+
+// Index maintains an in-memory index of notes.
+// It is safe for concurrent use by multiple readers and writers.
 type Index struct {
+	mu       sync.RWMutex
 	notes    []*note.Note
 	tagIndex map[string][]*note.Note
+	terms    *TermIndex
 }
 
 // NewIndex creates a new index
@@ -18,45 +24,72 @@ func NewIndex() *Index {
 	return &Index{
 		notes:    []*note.Note{},
 		tagIndex: make(map[string][]*note.Note),
+		terms:    NewTermIndex(),
 	}
 }
 
+// SetTermIndex replaces the term index used for content search
+func (idx *Index) SetTermIndex(terms *TermIndex) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.terms = terms
+}
+
+// Terms returns the term index used for content search
+func (idx *Index) Terms() *TermIndex {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return idx.terms
+}
+
 // AddNote adds a note to the index
 func (idx *Index) AddNote(n *note.Note) {
-	idx.notes = append(idx.notes, n)
-	idx.updateIndices(n)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.addNote(n)
 }
 
 // RemoveNote removes a note from the index
 func (idx *Index) RemoveNote(notePath string) {
-	for i, n := range idx.notes {
-		if n.Path == notePath {
-			idx.removeFromIndices(n)
-			idx.notes = append(idx.notes[:i], idx.notes[i+1:]...)
-			break
-		}
-	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeNote(notePath)
 }
 
 // UpdateNote updates a note in the index
 func (idx *Index) UpdateNote(n *note.Note) {
-	idx.RemoveNote(n.Path)
-	idx.AddNote(n)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeNote(n.Path)
+	idx.addNote(n)
 }
 
 // SearchByTag searches notes by tag
 func (idx *Index) SearchByTag(tag string) []*note.Note {
-	return idx.tagIndex[strings.ToLower(tag)]
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return append([]*note.Note(nil), idx.tagIndex[strings.ToLower(tag)]...)
 }
 
-// SearchByContent searches notes by content (simple text search)
+// SearchByContent searches notes whose name contains the query or whose
+// content contains every word of the query, also in the middle of a word
 func (idx *Index) SearchByContent(query string) []*note.Note {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
 	var results []*note.Note
 	queryLower := strings.ToLower(query)
+	queryTerms := Tokenize(query)
 
 	for _, n := range idx.notes {
-		if strings.Contains(strings.ToLower(n.Content), queryLower) ||
-			strings.Contains(strings.ToLower(n.Name), queryLower) {
+		if strings.Contains(strings.ToLower(n.Name), queryLower) ||
+			(len(queryTerms) > 0 && idx.terms.Match(n.Path, queryTerms)) {
 			results = append(results, n)
 		}
 	}
@@ -64,13 +97,20 @@ func (idx *Index) SearchByContent(query string) []*note.Note {
 	return results
 }
 
-// GetAllNotes returns all notes in the index
+// GetAllNotes returns all notes in the index.
+// The returned slice is a copy and may be reordered by the caller.
 func (idx *Index) GetAllNotes() []*note.Note {
-	return idx.notes
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return append([]*note.Note{}, idx.notes...)
 }
 
 // GetAllTags returns all unique tags
 func (idx *Index) GetAllTags() []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
 	var tags []string
 	for tag := range idx.tagIndex {
 		tags = append(tags, tag)
@@ -80,11 +120,31 @@ func (idx *Index) GetAllTags() []string {
 
 // RebuildIndex rebuilds the entire index
 func (idx *Index) RebuildIndex(notes []*note.Note) {
-	idx.notes = []*note.Note{}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.notes = make([]*note.Note, 0, len(notes))
 	idx.tagIndex = make(map[string][]*note.Note)
 
 	for _, n := range notes {
-		idx.AddNote(n)
+		idx.addNote(n)
+	}
+}
+
+// addNote adds a note; the caller must hold the write lock
+func (idx *Index) addNote(n *note.Note) {
+	idx.notes = append(idx.notes, n)
+	idx.updateIndices(n)
+}
+
+// removeNote removes a note by path; the caller must hold the write lock
+func (idx *Index) removeNote(notePath string) {
+	for i, n := range idx.notes {
+		if n.Path == notePath {
+			idx.removeFromIndices(n)
+			idx.notes = append(idx.notes[:i], idx.notes[i+1:]...)
+			break
+		}
 	}
 }
 
@@ -102,7 +162,12 @@ func (idx *Index) removeFromIndices(n *note.Note) {
 	// Remove from tag index
 	for _, tag := range n.Metadata.Tags {
 		tagLower := strings.ToLower(tag)
-		idx.removeNoteFromSlice(idx.tagIndex[tagLower], n)
+		remaining := idx.removeNoteFromSlice(idx.tagIndex[tagLower], n)
+		if len(remaining) == 0 {
+			delete(idx.tagIndex, tagLower)
+		} else {
+			idx.tagIndex[tagLower] = remaining
+		}
 	}
 }
 
@@ -116,9 +181,10 @@ func (idx *Index) removeNoteFromSlice(slice []*note.Note, noteToRemove *note.Not
 	return slice
 }
 
-// PrintStats prints index statistics
+// PrintStats logs index statistics
 func (idx *Index) PrintStats() {
-	fmt.Printf("Index Statistics:\n")
-	fmt.Printf("  Total notes: %d\n", len(idx.notes))
-	fmt.Printf("  Unique tags: %d\n", len(idx.tagIndex))
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	log.Info("index statistics", "notes", len(idx.notes), "tags", len(idx.tagIndex))
 }
@@ -0,0 +1,224 @@
+package index
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"notes-app/internal/note"
+)
+
+// termIndexVersion is bumped whenever the tokenizer or file layout changes,
+// which makes older index files rebuild from scratch
+const termIndexVersion = 1
+
+// TermIndex maps notes to the words they contain so content search works
+// without keeping note contents in memory. It can be persisted to a file
+// and is refreshed incrementally: only notes whose size or modification
+// time changed are read again.
+type TermIndex struct {
+	mu      sync.RWMutex
+	file    string
+	entries map[string]*termEntry
+	dirty   bool
+}
+
+// termEntry holds the sorted unique terms of one note
+type termEntry struct {
+	ModTime time.Time
+	Size    int64
+	Terms   []string
+}
+
+// termIndexFile is the on-disk representation of a TermIndex
+type termIndexFile struct {
+	Version int
+	Entries map[string]*termEntry
+}
+
+// NewTermIndex creates an empty term index that is not persisted
+func NewTermIndex() *TermIndex {
+	return &TermIndex{
+		entries: make(map[string]*termEntry),
+	}
+}
+
+// LoadTermIndex reads a persisted term index. A missing, corrupt or
+// outdated file results in an empty index that is rebuilt on Sync.
+func LoadTermIndex(file string) (*TermIndex, error) {
+	ti := NewTermIndex()
+	ti.file = file
+
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ti, nil
+		}
+		return ti, fmt.Errorf("failed to open search index: %w", err)
+	}
+	defer f.Close()
+
+	var data termIndexFile
+	if err := gob.NewDecoder(f).Decode(&data); err != nil {
+		return ti, fmt.Errorf("failed to read search index: %w", err)
+	}
+	if data.Version == termIndexVersion && data.Entries != nil {
+		ti.entries = data.Entries
+	}
+
+	return ti, nil
+}
+
+// Sync brings the index up to date with the given notes, reading the
+// content of new and changed notes through load. Notes whose content
+// cannot be read are left out of content search.
+func (ti *TermIndex) Sync(ctx context.Context, notes []*note.Note, load func(*note.Note) (string, error)) error {
+	seen := make(map[string]bool, len(notes))
+
+	for _, n := range notes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		seen[n.Path] = true
+
+		ti.mu.RLock()
+		existing := ti.entries[n.Path]
+		ti.mu.RUnlock()
+		if existing != nil && existing.Size == n.Size && existing.ModTime.Equal(n.ModTime) {
+			continue
+		}
+
+		content, err := load(n)
+		if err != nil {
+			// One unreadable note should not break search for the others
+			log.Warn("failed to index note", "path", n.Path, "err", err)
+			continue
+		}
+		ti.Set(n, content)
+	}
+
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	for path := range ti.entries {
+		if !seen[path] {
+			delete(ti.entries, path)
+			ti.dirty = true
+		}
+	}
+
+	return nil
+}
+
+// Set indexes the content of a single note
+func (ti *TermIndex) Set(n *note.Note, content string) {
+	entry := &termEntry{
+		ModTime: n.ModTime,
+		Size:    n.Size,
+		Terms:   uniqueTerms(content),
+	}
+
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	ti.entries[n.Path] = entry
+	ti.dirty = true
+}
+
+// Match reports whether the note contains every query term
+// anywhere within one of its words
+func (ti *TermIndex) Match(notePath string, queryTerms []string) bool {
+	ti.mu.RLock()
+	defer ti.mu.RUnlock()
+
+	entry := ti.entries[notePath]
+	if entry == nil {
+		return false
+	}
+
+	for _, q := range queryTerms {
+		if !entry.contains(q) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains reports whether a term of the note contains q. Word prefixes
+// are found by binary search; only other matches scan every term.
+func (e *termEntry) contains(q string) bool {
+	if i := sort.SearchStrings(e.Terms, q); i < len(e.Terms) && strings.HasPrefix(e.Terms[i], q) {
+		return true
+	}
+	for _, term := range e.Terms {
+		if strings.Contains(term, q) {
+			return true
+		}
+	}
+	return false
+}
+
+// Save writes the index to its file if it changed since it was loaded
+func (ti *TermIndex) Save() error {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+
+	if ti.file == "" || !ti.dirty {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ti.file), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated index
+	tmp := ti.file + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+
+	if err := gob.NewEncoder(f).Encode(termIndexFile{Version: termIndexVersion, Entries: ti.entries}); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+
+	if err := os.Rename(tmp, ti.file); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+
+	ti.dirty = false
+	return nil
+}
+
+// Tokenize splits text into lowercase words
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// uniqueTerms returns the sorted unique words of the text
+func uniqueTerms(text string) []string {
+	set := make(map[string]struct{})
+	for _, t := range Tokenize(text) {
+		set[t] = struct{}{}
+	}
+
+	terms := make([]string, 0, len(set))
+	for t := range set {
+		terms = append(terms, t)
+	}
+	sort.Strings(terms)
+	return terms
+}
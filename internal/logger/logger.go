@@ -1,30 +1,224 @@
 package logger
 
 import (
+	"context"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 )
 
-var (
-	debugLogger *log.Logger
-	isDebug     bool
+// Options configure where and how much is logged
+type Options struct {
+	// Dir is the directory holding the log file
+	Dir string
+	// Levels is a level spec such as "info" or "warn,storage=debug,ui=error"
+	Levels string
+	// MaxSize is the size in bytes at which the log file is rotated
+	MaxSize int64
+	// MaxBackups is the number of rotated files kept
+	MaxBackups int
+}
+
+// FileName is the name of the active log file
+const FileName = "notes-app.log"
+
+const (
+	defaultMaxSize    = 5 << 20
+	defaultMaxBackups = 3
 )
 
+// output is the shared state behind every package logger
+type output struct {
+	handler slog.Handler
+	levels  *levelSpec
+	file    io.Closer
+}
+
+var current atomic.Pointer[output]
+
 func init() {
-	// Check if debug logging is enabled via environment variable
+	// Until Init is called nothing is written, so the TUI is never disturbed
+	current.Store(&output{
+		handler: slog.NewTextHandler(io.Discard, nil),
+		levels:  &levelSpec{defaultLevel: slog.LevelError},
+	})
+}
+
+// DefaultLevels returns the level spec used when none is configured.
+// NOTESAPP_LOG_LEVEL takes precedence over NOTESAPP_DEBUG.
+func DefaultLevels() string {
+	if levels := os.Getenv("NOTESAPP_LOG_LEVEL"); levels != "" {
+		return levels
+	}
 	if os.Getenv("NOTESAPP_DEBUG") != "" {
-		isDebug = true
-		debugLogger = log.New(os.Stdout, "[DEBUG] ", log.Ldate|log.Ltime|log.Lshortfile)
-	} else {
-		// If debug is disabled, use discard writer to silence output
-		debugLogger = log.New(io.Discard, "", 0)
+		return "debug"
+	}
+	return "info"
+}
+
+// Init starts writing JSON log records to a rotating file in opts.Dir
+func Init(opts Options) error {
+	levels, err := parseLevels(opts.Levels)
+	if err != nil {
+		return err
+	}
+
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = defaultMaxSize
+	}
+	if opts.MaxBackups <= 0 {
+		opts.MaxBackups = defaultMaxBackups
+	}
+
+	file, err := openRotatingFile(filepath.Join(opts.Dir, FileName), opts.MaxSize, opts.MaxBackups)
+	if err != nil {
+		return err
+	}
+
+	// The handler itself accepts everything; filtering happens per package
+	handler := slog.NewJSONHandler(file, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     slog.LevelDebug,
+	})
+
+	previous := current.Swap(&output{handler: handler, levels: levels, file: file})
+	if previous.file != nil {
+		previous.file.Close()
+	}
+	return nil
+}
+
+// Close flushes and closes the log file
+func Close() error {
+	out := current.Load()
+	if out.file == nil {
+		return nil
+	}
+	return out.file.Close()
+}
+
+// Path returns the log file path for a directory
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// For returns the logger for a package. It may be called before Init;
+// records are routed to whatever output is active when they are logged.
+func For(pkg string) *slog.Logger {
+	return slog.New(&packageHandler{pkg: pkg}).With("pkg", pkg)
+}
+
+// packageHandler applies the level configured for its package and
+// forwards records to the current output
+type packageHandler struct {
+	pkg string
+	// wraps are the WithAttrs and WithGroup calls, in the order they were made
+	wraps []func(slog.Handler) slog.Handler
+	built atomic.Pointer[builtHandler]
+}
+
+// builtHandler is the output handler with the wraps of a packageHandler applied
+type builtHandler struct {
+	output  slog.Handler
+	handler slog.Handler
+}
+
+// handler returns the current output handler with the wraps applied,
+// building it only when the output changed since the last record
+func (h *packageHandler) handler() slog.Handler {
+	output := current.Load().handler
+	if b := h.built.Load(); b != nil && b.output == output {
+		return b.handler
+	}
+	handler := output
+	for _, wrap := range h.wraps {
+		handler = wrap(handler)
+	}
+	h.built.Store(&builtHandler{output: output, handler: handler})
+	return handler
+}
+
+// wrap returns a copy of h with one more wrap applied after the others
+func (h *packageHandler) wrap(wrap func(slog.Handler) slog.Handler) *packageHandler {
+	wraps := append(append([]func(slog.Handler) slog.Handler{}, h.wraps...), wrap)
+	return &packageHandler{pkg: h.pkg, wraps: wraps}
+}
+
+func (h *packageHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= current.Load().levels.levelFor(h.pkg)
+}
+
+func (h *packageHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler().Handle(ctx, r)
+}
+
+func (h *packageHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.wrap(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *packageHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
 	}
+	return h.wrap(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+// levelSpec holds a default level and per-package overrides
+type levelSpec struct {
+	defaultLevel slog.Level
+	packages     map[string]slog.Level
 }
 
-// Debug logs a debug message if debug logging is enabled
-func Debug(format string, v ...interface{}) {
-	if isDebug {
-		debugLogger.Printf(format, v...)
+// levelFor returns the minimum level logged for a package
+func (s *levelSpec) levelFor(pkg string) slog.Level {
+	if level, ok := s.packages[pkg]; ok {
+		return level
 	}
+	return s.defaultLevel
+}
+
+// ValidateLevels checks a level spec such as "warn,storage=debug"
+func ValidateLevels(spec string) error {
+	_, err := parseLevels(spec)
+	return err
+}
+
+// parseLevels parses a level spec such as "warn,storage=debug"
+func parseLevels(spec string) (*levelSpec, error) {
+	levels := &levelSpec{
+		defaultLevel: slog.LevelInfo,
+		packages:     make(map[string]slog.Level),
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		pkg, name, hasPkg := strings.Cut(part, "=")
+		if !hasPkg {
+			name, pkg = pkg, ""
+		}
+
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+			return nil, fmt.Errorf("invalid log level '%s' (expected debug, info, warn or error)", name)
+		}
+
+		if hasPkg {
+			levels.packages[strings.TrimSpace(pkg)] = level
+		} else {
+			levels.defaultLevel = level
+		}
+	}
+
+	return levels, nil
 }
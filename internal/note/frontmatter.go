@@ -0,0 +1,88 @@
+package note
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MetadataFormat describes where a note keeps its metadata
+type MetadataFormat string
+
+const (
+	// FormatSidecar stores metadata as JSON in a .meta file next to the note
+	FormatSidecar MetadataFormat = "sidecar"
+	// FormatFrontMatter stores metadata as YAML front matter inside the note
+	FormatFrontMatter MetadataFormat = "frontmatter"
+)
+
+const frontMatterDelimiter = "---"
+
+// ParseMetadataFormat validates a metadata format name
+func ParseMetadataFormat(s string) (MetadataFormat, error) {
+	switch MetadataFormat(strings.ToLower(strings.TrimSpace(s))) {
+	case FormatSidecar, "":
+		return FormatSidecar, nil
+	case FormatFrontMatter, "front-matter", "yaml":
+		return FormatFrontMatter, nil
+	default:
+		return "", fmt.Errorf("unknown metadata format '%s' (expected %s or %s)", s, FormatSidecar, FormatFrontMatter)
+	}
+}
+
+// SplitFrontMatter separates YAML front matter from the note body.
+// It returns nil metadata if the data does not start with front matter.
+func SplitFrontMatter(data []byte) (*Metadata, string, error) {
+	text := string(data)
+	firstLine, rest, ok := cutLine(text)
+	if !ok || strings.TrimRight(firstLine, " \t") != frontMatterDelimiter {
+		return nil, text, nil
+	}
+
+	var header strings.Builder
+	for {
+		line, remaining, more := cutLine(rest)
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed == frontMatterDelimiter || trimmed == "..." {
+			rest = remaining
+			break
+		}
+		if !more {
+			// No closing delimiter, so this is not front matter
+			return nil, text, nil
+		}
+		header.WriteString(line)
+		header.WriteString("\n")
+		rest = remaining
+	}
+
+	var meta Metadata
+	if err := yaml.Unmarshal([]byte(header.String()), &meta); err != nil {
+		return nil, text, fmt.Errorf("failed to parse front matter YAML: %w", err)
+	}
+
+	return &meta, rest, nil
+}
+
+// RenderFrontMatter prepends metadata as YAML front matter to the body
+func RenderFrontMatter(meta *Metadata, body string) ([]byte, error) {
+	header, err := yaml.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal front matter: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelimiter + "\n")
+	buf.Write(header)
+	buf.WriteString(frontMatterDelimiter + "\n")
+	buf.WriteString(body)
+	return buf.Bytes(), nil
+}
+
+// cutLine splits off the first line, accepting both \n and \r\n endings
+func cutLine(s string) (line, rest string, found bool) {
+	line, rest, found = strings.Cut(s, "\n")
+	return strings.TrimSuffix(line, "\r"), rest, found
+}
@@ -2,39 +2,136 @@ package note
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
+// CurrentSchemaVersion is the version of the metadata schema written by this build.
+//
+// Version history:
+//
+//	1 - tags only (files without a schema_version field)
+//	2 - timestamps, title, aliases, pinned/archived flags and custom fields
+const CurrentSchemaVersion = 2
+
 // Metadata represents the metadata for a note
 type Metadata struct {
-	Tags []string `json:"tags"`
+	SchemaVersion int               `json:"schema_version" yaml:"schema_version"`
+	Title         string            `json:"title,omitempty" yaml:"title,omitempty"`
+	Aliases       []string          `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Tags          []string          `json:"tags" yaml:"tags"`
+	Created       time.Time         `json:"created" yaml:"created"`
+	Updated       time.Time         `json:"updated" yaml:"updated"`
+	Pinned        bool              `json:"pinned,omitempty" yaml:"pinned,omitempty"`
+	Archived      bool              `json:"archived,omitempty" yaml:"archived,omitempty"`
+	Fields        map[string]string `json:"fields,omitempty" yaml:"fields,omitempty"`
+
+	// Extra keeps front matter keys written by other tools so that
+	// converting between layouts does not drop them
+	Extra map[string]interface{} `json:"extra,omitempty" yaml:",inline"`
+}
+
+// ErrInvalidMetadata is matched by errors for metadata that cannot be parsed
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// MetadataError reports metadata that exists but cannot be parsed
+type MetadataError struct {
+	// Path is the file holding the broken metadata
+	Path string
+	Err  error
+}
+
+func (e *MetadataError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MetadataError) Unwrap() error {
+	return e.Err
 }
 
-// LoadMetadata loads metadata from a .meta file
-func LoadMetadata(metaPath string) (*Metadata, error) {
+// Is makes every MetadataError match ErrInvalidMetadata
+func (e *MetadataError) Is(target error) bool {
+	return target == ErrInvalidMetadata
+}
+
+// NewMetadata creates empty metadata with both timestamps set to the given time
+func NewMetadata(created time.Time) *Metadata {
+	return &Metadata{
+		SchemaVersion: CurrentSchemaVersion,
+		Tags:          []string{},
+		Created:       created,
+		Updated:       created,
+	}
+}
+
+// LoadMetadata loads metadata from a .meta file.
+// The fallback time is used for timestamps missing from older files.
+// Files written with an older schema are migrated in memory only; the
+// file keeps its old schema until the note is saved or migrated.
+func LoadMetadata(metaPath string, fallback time.Time) (*Metadata, error) {
 	data, err := os.ReadFile(metaPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &Metadata{
-				Tags: []string{},
-			}, nil
+			return NewMetadata(fallback), nil
 		}
 		return nil, fmt.Errorf("failed to read metadata file: %w", err)
 	}
 
 	var meta Metadata
 	if err := json.Unmarshal(data, &meta); err != nil {
-		return nil, fmt.Errorf("failed to parse metadata JSON: %w", err)
+		return nil, &MetadataError{Path: metaPath, Err: fmt.Errorf("failed to parse metadata JSON: %w", err)}
 	}
 
+	meta.Migrate(fallback)
+	return &meta, nil
+}
+
+// Migrate upgrades metadata from an older schema version in place.
+// It returns true if anything was changed.
+func (m *Metadata) Migrate(fallback time.Time) bool {
 	// Ensure slice is not nil
-	if meta.Tags == nil {
-		meta.Tags = []string{}
+	if m.Tags == nil {
+		m.Tags = []string{}
 	}
 
-	return &meta, nil
+	if m.SchemaVersion >= CurrentSchemaVersion {
+		return false
+	}
+
+	// Version 1 -> 2: timestamps were only known from the file itself
+	if m.Created.IsZero() {
+		m.Created = fallback
+	}
+	if m.Updated.IsZero() {
+		m.Updated = m.Created
+	}
+
+	m.SchemaVersion = CurrentSchemaVersion
+	return true
+}
+
+// Touch marks the metadata as updated at the given time
+func (m *Metadata) Touch(now time.Time) {
+	if m.Created.IsZero() {
+		m.Created = now
+	}
+	m.Updated = now
+	m.SchemaVersion = CurrentSchemaVersion
+}
+
+// SetField sets a custom field, removing it if value is empty
+func (m *Metadata) SetField(key, value string) {
+	if value == "" {
+		delete(m.Fields, key)
+		return
+	}
+	if m.Fields == nil {
+		m.Fields = make(map[string]string)
+	}
+	m.Fields[key] = value
 }
 
 // SaveMetadata saves metadata to a .meta file
@@ -56,3 +153,23 @@ func (m *Metadata) SaveMetadata(metaPath string) error {
 
 	return nil
 }
+
+// Clone returns a deep copy of the metadata
+func (m *Metadata) Clone() *Metadata {
+	c := *m
+	c.Aliases = append([]string(nil), m.Aliases...)
+	c.Tags = append([]string{}, m.Tags...)
+	if m.Fields != nil {
+		c.Fields = make(map[string]string, len(m.Fields))
+		for k, v := range m.Fields {
+			c.Fields[k] = v
+		}
+	}
+	if m.Extra != nil {
+		c.Extra = make(map[string]interface{}, len(m.Extra))
+		for k, v := range m.Extra {
+			c.Extra[k] = v
+		}
+	}
+	return &c
+}
@@ -0,0 +1,118 @@
+package note
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLoadMetadata(t *testing.T) {
+	fallback := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	created := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		data        string
+		wantTags    []string
+		wantCreated time.Time
+		wantUpdated time.Time
+	}{
+		{
+			name:        "version 1 without timestamps",
+			data:        `{"tags":["work"]}`,
+			wantTags:    []string{"work"},
+			wantCreated: fallback,
+			wantUpdated: fallback,
+		},
+		{
+			name:        "version 1 without tags",
+			data:        `{}`,
+			wantTags:    []string{},
+			wantCreated: fallback,
+			wantUpdated: fallback,
+		},
+		{
+			name:        "current version",
+			data:        `{"schema_version":2,"tags":["a"],"created":"2023-01-02T03:04:05Z","updated":"2023-01-02T03:04:05Z"}`,
+			wantTags:    []string{"a"},
+			wantCreated: created,
+			wantUpdated: created,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "note.meta")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			meta, err := LoadMetadata(path, fallback)
+			if err != nil {
+				t.Fatalf("failed to load metadata: %v", err)
+			}
+			if meta.SchemaVersion != CurrentSchemaVersion {
+				t.Errorf("schema version = %d, want %d", meta.SchemaVersion, CurrentSchemaVersion)
+			}
+			if !slices.Equal(meta.Tags, tt.wantTags) || meta.Tags == nil {
+				t.Errorf("tags = %#v, want %#v", meta.Tags, tt.wantTags)
+			}
+			if !meta.Created.Equal(tt.wantCreated) || !meta.Updated.Equal(tt.wantUpdated) {
+				t.Errorf("timestamps = %v, %v, want %v, %v", meta.Created, meta.Updated, tt.wantCreated, tt.wantUpdated)
+			}
+
+			// Reading never rewrites the file, whatever its version
+			if data, _ := os.ReadFile(path); string(data) != tt.data {
+				t.Errorf("file changed to %s", data)
+			}
+		})
+	}
+}
+
+func TestLoadMetadataInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.meta")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadMetadata(path, time.Now())
+	var metaErr *MetadataError
+	if !errors.As(err, &metaErr) || metaErr.Path != path {
+		t.Errorf("error = %v, want a metadata error for %s", err, path)
+	}
+}
+
+func TestNoteSaveUpgradesMetadata(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "todo.md")
+	if err := os.WriteFile(path, []byte("buy milk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(MetaPathFor(path), []byte(`{"tags":["home"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := LoadNote(path, FormatSidecar)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	if err := n.Write(); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+
+	// The file itself is now in the current schema
+	data, err := os.ReadFile(MetaPathFor(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("failed to parse saved metadata: %v", err)
+	}
+	if meta.SchemaVersion != CurrentSchemaVersion || !slices.Equal(meta.Tags, []string{"home"}) || meta.Created.IsZero() {
+		t.Errorf("saved metadata = %+v, want the tags in the current schema with timestamps", meta)
+	}
+}
@@ -1,13 +1,23 @@
 package note
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
+const (
+	// DefaultExtension is the extension of notes created by this application
+	DefaultExtension = ".note"
+	// MetaExtension is the extension of sidecar metadata files
+	MetaExtension = ".meta"
+)
+
 // Note represents a single note with its content and metadata
 type Note struct {
 	Path     string
@@ -15,47 +25,80 @@ type Note struct {
 	Content  string
 	Metadata *Metadata
 	ModTime  time.Time
+	Size     int64
+	Format   MetadataFormat
+
+	// ContentLoaded is false for notes listed without their content;
+	// use LoadContent or the storage backend to fetch it
+	ContentLoaded bool
 }
 
 // NewNote creates a new note
 func NewNote(notePath string) *Note {
-	name := strings.TrimSuffix(filepath.Base(notePath), ".note")
+	base := filepath.Base(notePath)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
 	return &Note{
-		Path:    notePath,
-		Name:    name,
-		Content: "",
-		Metadata: &Metadata{
-			Tags: []string{},
-		},
+		Path:     notePath,
+		Name:     name,
+		Content:  "",
+		Metadata: NewMetadata(time.Time{}),
+		Format:   FormatSidecar,
+
+		ContentLoaded: true,
 	}
 }
 
-// LoadNote loads a note from the filesystem
-func LoadNote(notePath string) (*Note, error) {
-	// Check if note file exists
-	if _, err := os.Stat(notePath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("note file does not exist: %s", notePath)
+// LoadNote loads a note including its content from the filesystem
+func LoadNote(notePath string, format MetadataFormat) (*Note, error) {
+	note, err := LoadNoteInfo(notePath, format)
+	if err != nil {
+		return nil, err
 	}
 
-	note := NewNote(notePath)
-
-	// Load content
-	content, err := os.ReadFile(notePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read note content: %w", err)
+	if err := note.LoadContent(); err != nil {
+		return nil, err
 	}
-	note.Content = string(content)
 
-	// Get modification time
+	return note, nil
+}
+
+// LoadNoteInfo loads a note's file info and metadata without its content.
+// In front matter vaults, notes that still have a .meta file and no
+// front matter are read from the sidecar until they are saved again.
+func LoadNoteInfo(notePath string, format MetadataFormat) (*Note, error) {
+	// Check if note file exists
 	info, err := os.Stat(notePath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("note file does not exist: %w", err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
+
+	note := NewNote(notePath)
 	note.ModTime = info.ModTime()
+	note.Size = info.Size()
+	note.Format = format
+	note.ContentLoaded = false
 
-	// Load metadata
-	metaPath := strings.TrimSuffix(notePath, ".note") + ".meta"
-	metadata, err := LoadMetadata(metaPath)
+	if format == FormatFrontMatter {
+		header, err := readFrontMatter(notePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read note content: %w", err)
+		}
+		meta, _, err := SplitFrontMatter(header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load metadata: %w", &MetadataError{Path: notePath, Err: err})
+		}
+		if meta != nil {
+			meta.Migrate(note.ModTime)
+			note.Metadata = meta
+			return note, nil
+		}
+	}
+
+	// Load metadata, falling back to the file time for missing timestamps
+	metadata, err := LoadMetadata(note.GetMetaPath(), note.ModTime)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load metadata: %w", err)
 	}
@@ -64,25 +107,117 @@ func LoadNote(notePath string) (*Note, error) {
 	return note, nil
 }
 
+// LoadContent reads the note body from disk, skipping any front matter
+func (n *Note) LoadContent() error {
+	content, err := os.ReadFile(n.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read note content: %w", err)
+	}
+	n.Content = string(content)
+
+	if n.Format == FormatFrontMatter {
+		meta, body, err := SplitFrontMatter(content)
+		if err != nil {
+			return fmt.Errorf("failed to load metadata: %w", &MetadataError{Path: n.Path, Err: err})
+		}
+		if meta != nil {
+			n.Content = body
+		}
+	}
+
+	n.ContentLoaded = true
+	return nil
+}
+
+// readFrontMatter reads only the front matter block at the start of a
+// file, so metadata can be loaded without reading the whole note
+func readFrontMatter(notePath string) ([]byte, error) {
+	f, err := os.Open(notePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var header bytes.Buffer
+	for lineNo := 0; ; lineNo++ {
+		line, err := r.ReadString('\n')
+		header.WriteString(line)
+
+		trimmed := strings.TrimRight(line, " \t\r\n")
+		if lineNo == 0 && trimmed != frontMatterDelimiter {
+			return nil, nil
+		}
+		if lineNo > 0 && (trimmed == frontMatterDelimiter || trimmed == "...") {
+			return header.Bytes(), nil
+		}
+		if err == io.EOF {
+			return header.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
 // Save saves the note and its metadata to the filesystem
 func (n *Note) Save() error {
+	n.Metadata.Touch(time.Now())
+	return n.Write()
+}
+
+// Write persists content and metadata according to the note's format
+// without updating any timestamps
+func (n *Note) Write() error {
+	if err := n.WriteFile(); err != nil {
+		return err
+	}
+
+	if n.Format == FormatFrontMatter {
+		// Metadata now lives in the note, so drop any old sidecar
+		if err := os.Remove(n.GetMetaPath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove metadata file: %w", err)
+		}
+		return nil
+	}
+
+	// Save metadata
+	if err := n.Metadata.SaveMetadata(n.GetMetaPath()); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
+
+	return nil
+}
+
+// WriteFile writes the note file according to the note's format, with
+// front matter if it has one, and leaves any sidecar file as it is
+func (n *Note) WriteFile() error {
+	// Never replace the file with an empty body because it was listed lazily
+	if !n.ContentLoaded {
+		if err := n.LoadContent(); err != nil {
+			return err
+		}
+	}
+
 	// Ensure directory exists
 	dir := filepath.Dir(n.Path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// Save note content
-	if err := os.WriteFile(n.Path, []byte(n.Content), 0644); err != nil {
-		return fmt.Errorf("failed to save note content: %w", err)
+	data := []byte(n.Content)
+	if n.Format == FormatFrontMatter {
+		var err error
+		data, err = RenderFrontMatter(n.Metadata, n.Content)
+		if err != nil {
+			return fmt.Errorf("failed to save metadata: %w", err)
+		}
 	}
 
-	// Save metadata
-	metaPath := strings.TrimSuffix(n.Path, ".note") + ".meta"
-	if err := n.Metadata.SaveMetadata(metaPath); err != nil {
-		return fmt.Errorf("failed to save metadata: %w", err)
+	// Save note content
+	if err := os.WriteFile(n.Path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save note content: %w", err)
 	}
-
 	return nil
 }
 
@@ -94,8 +229,7 @@ func (n *Note) Delete() error {
 	}
 
 	// Delete metadata file
-	metaPath := strings.TrimSuffix(n.Path, ".note") + ".meta"
-	if err := os.Remove(metaPath); err != nil && !os.IsNotExist(err) {
+	if err := os.Remove(n.GetMetaPath()); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete metadata file: %w", err)
 	}
 
@@ -104,5 +238,30 @@ func (n *Note) Delete() error {
 
 // GetMetaPath returns the path to the metadata file
 func (n *Note) GetMetaPath() string {
-	return strings.TrimSuffix(n.Path, ".note") + ".meta"
+	return MetaPathFor(n.Path)
+}
+
+// MetaPathFor returns the sidecar metadata path for a note file.
+// ".note" files use "name.meta"; other extensions keep their extension
+// ("name.md.meta") so notes that share a name do not share metadata.
+func MetaPathFor(notePath string) string {
+	if strings.EqualFold(filepath.Ext(notePath), DefaultExtension) {
+		return notePath[:len(notePath)-len(DefaultExtension)] + MetaExtension
+	}
+	return notePath + MetaExtension
+}
+
+// DisplayTitle returns the explicit title if set, otherwise the file name
+func (n *Note) DisplayTitle() string {
+	if n.Metadata != nil && n.Metadata.Title != "" {
+		return n.Metadata.Title
+	}
+	return n.Name
+}
+
+// Clone returns a copy of the note that shares no mutable state with it
+func (n *Note) Clone() *Note {
+	c := *n
+	c.Metadata = n.Metadata.Clone()
+	return &c
 }
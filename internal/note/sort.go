@@ -0,0 +1,138 @@
+package note
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortField is a note attribute the note list can be sorted by
+type SortField string
+
+const (
+	SortByName     SortField = "name"
+	SortByModified SortField = "modified"
+	SortByCreated  SortField = "created"
+	SortBySize     SortField = "size"
+	SortByTags     SortField = "tags"
+)
+
+// SortFields lists the sort fields in the order they are cycled through
+var SortFields = []SortField{SortByName, SortByModified, SortByCreated, SortBySize, SortByTags}
+
+// SortOrder describes how notes are ordered
+type SortOrder struct {
+	Field      SortField
+	Descending bool
+}
+
+// DefaultSortOrder is used when no sort order has been chosen
+var DefaultSortOrder = SortOrder{Field: SortByName}
+
+// ParseSortOrder parses strings like "name", "modified:desc" or "size:asc"
+func ParseSortOrder(s string) (SortOrder, error) {
+	if strings.TrimSpace(s) == "" {
+		return DefaultSortOrder, nil
+	}
+
+	fieldName, direction, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+	order := SortOrder{Field: SortField(fieldName)}
+
+	valid := false
+	for _, f := range SortFields {
+		if f == order.Field {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return DefaultSortOrder, fmt.Errorf("unknown sort field '%s'", fieldName)
+	}
+
+	switch direction {
+	case "", "asc":
+	case "desc":
+		order.Descending = true
+	default:
+		return DefaultSortOrder, fmt.Errorf("unknown sort direction '%s' (expected asc or desc)", direction)
+	}
+
+	return order, nil
+}
+
+// String formats the sort order so that ParseSortOrder can read it back
+func (o SortOrder) String() string {
+	if o.Descending {
+		return string(o.Field) + ":desc"
+	}
+	return string(o.Field) + ":asc"
+}
+
+// NextField returns the sort order using the next field in SortFields
+func (o SortOrder) NextField() SortOrder {
+	for i, f := range SortFields {
+		if f == o.Field {
+			o.Field = SortFields[(i+1)%len(SortFields)]
+			return o
+		}
+	}
+	o.Field = SortFields[0]
+	return o
+}
+
+// Reversed returns the sort order with the direction flipped
+func (o SortOrder) Reversed() SortOrder {
+	o.Descending = !o.Descending
+	return o
+}
+
+// SortNotes sorts notes in place. Pinned notes always come first,
+// ties are broken by name so the order is stable between refreshes.
+func SortNotes(notes []*Note, order SortOrder) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if a.Metadata.Pinned != b.Metadata.Pinned {
+			return a.Metadata.Pinned
+		}
+
+		cmp := compareNotes(a, b, order.Field)
+		if cmp == 0 {
+			cmp = strings.Compare(strings.ToLower(a.DisplayTitle()), strings.ToLower(b.DisplayTitle()))
+			if cmp == 0 {
+				cmp = strings.Compare(a.Path, b.Path)
+			}
+		}
+		if order.Descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
+
+// compareNotes compares two notes by a single field
+func compareNotes(a, b *Note, field SortField) int {
+	switch field {
+	case SortByModified:
+		return a.ModTime.Compare(b.ModTime)
+	case SortByCreated:
+		return a.Metadata.Created.Compare(b.Metadata.Created)
+	case SortBySize:
+		return compareInt(a.Size, b.Size)
+	case SortByTags:
+		return compareInt(int64(len(a.Metadata.Tags)), int64(len(b.Metadata.Tags)))
+	default:
+		return 0
+	}
+}
+
+// compareInt returns -1, 0 or 1 depending on how a compares to b
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
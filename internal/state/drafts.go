@@ -0,0 +1,124 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"notes-app/internal/common"
+)
+
+// Draft is the unsaved text of a note being written or edited, kept so it
+// survives the app closing before it is saved
+type Draft struct {
+	Vault   string    `json:"vault"`
+	Path    string    `json:"path,omitempty"` // empty for a note not created yet
+	Name    string    `json:"name"`
+	Content string    `json:"content"`
+	Saved   time.Time `json:"saved"`
+	// Session is the run of the app that wrote the draft, see NewDraftSession
+	Session string `json:"session,omitempty"`
+
+	id string // name of the file the draft was read from
+}
+
+// NewDraftSession returns a value for Draft.Session that no other run of
+// the app uses, so drafts of different runs never replace each other
+func NewDraftSession() string {
+	return fmt.Sprintf("%d-%d", time.Now().UnixNano(), os.Getpid())
+}
+
+// ID identifies the draft of a note within its session, so a newer draft
+// replaces an older one of the same session only
+func (d Draft) ID() string {
+	if d.id != "" {
+		return d.id
+	}
+	sum := sha256.Sum256([]byte(d.Vault + "\x00" + d.Path + "\x00" + d.Name + "\x00" + d.Session))
+	return hex.EncodeToString(sum[:8])
+}
+
+// draftsDir returns the directory drafts are kept in
+func draftsDir() string {
+	return filepath.Join(common.GetStateDir(), "drafts")
+}
+
+// draftPath returns the path of the file of a draft
+func draftPath(id string) string {
+	return filepath.Join(draftsDir(), id+".json")
+}
+
+// SaveDraft writes a draft, replacing an older draft of the same note
+func SaveDraft(d Draft) error {
+	// Drafts hold note content, which only the user should read
+	if err := os.MkdirAll(draftsDir(), 0700); err != nil {
+		return fmt.Errorf("failed to create drafts directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal draft: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves half a draft
+	path := draftPath(d.ID())
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return fmt.Errorf("failed to write draft: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write draft: %w", err)
+	}
+	return nil
+}
+
+// LoadDrafts reads every draft, newest first. Drafts that cannot be read
+// are reported in the error and left out.
+func LoadDrafts() ([]Draft, error) {
+	entries, err := os.ReadDir(draftsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read drafts directory: %w", err)
+	}
+
+	var drafts []Draft
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(draftsDir(), entry.Name()))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read draft %s: %w", entry.Name(), err))
+			continue
+		}
+		var d Draft
+		if err := json.Unmarshal(data, &d); err != nil {
+			errs = append(errs, fmt.Errorf("failed to parse draft %s: %w", entry.Name(), err))
+			continue
+		}
+		d.id = strings.TrimSuffix(entry.Name(), ".json")
+		drafts = append(drafts, d)
+	}
+
+	sort.Slice(drafts, func(i, j int) bool {
+		return drafts[i].Saved.After(drafts[j].Saved)
+	})
+	return drafts, errors.Join(errs...)
+}
+
+// DeleteDraft removes a draft once its text was saved or given up
+func DeleteDraft(id string) error {
+	if err := os.Remove(draftPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete draft: %w", err)
+	}
+	return nil
+}
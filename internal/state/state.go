@@ -0,0 +1,59 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"notes-app/internal/common"
+)
+
+// State holds UI choices that are remembered between sessions
+type State struct {
+	SortOrder  string  `json:"sort_order,omitempty"`
+	SplitRatio float64 `json:"split_ratio,omitempty"`
+	Theme      string  `json:"theme,omitempty"`
+}
+
+// statePath returns the path of the session state file
+func statePath() string {
+	return filepath.Join(common.GetStateDir(), "state.json")
+}
+
+// Load reads the session state, returning an empty state if none was saved
+func Load() (*State, error) {
+	data, err := os.ReadFile(statePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return &State{}, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return &State{}, fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	return &s, nil
+}
+
+// Save writes the session state
+func (s *State) Save() error {
+	path := statePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	return nil
+}
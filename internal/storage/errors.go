@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"errors"
+	iofs "io/fs"
+
+	"notes-app/internal/note"
+)
+
+// Errors wrapped by the storage backends so callers can tell failures
+// apart with errors.Is
+var (
+	// ErrNotFound means the note does not exist
+	ErrNotFound = errors.New("note does not exist")
+	// ErrAlreadyExists means another note already has the name
+	ErrAlreadyExists = errors.New("note already exists")
+	// ErrPermission means the vault cannot be read or written. It is
+	// io/fs.ErrPermission, so errors from the os package match it too.
+	ErrPermission = iofs.ErrPermission
+	// ErrInvalidMetadata means the metadata of a note cannot be parsed
+	ErrInvalidMetadata = note.ErrInvalidMetadata
+)
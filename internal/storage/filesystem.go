@@ -1,124 +1,414 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	iofs "io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
-	"notes-app/internal/logger"
+	"golang.org/x/sync/errgroup"
 	"notes-app/internal/note"
 )
 
 // FileSystemStorage handles file system operations for notes
 type FileSystemStorage struct {
-	rootPath string
+	rootPath         string
+	settings         *VaultSettings
+	defaultExtension string
 }
 
-// NewFileSystemStorage creates a new filesystem storage
+// NewFileSystemStorage creates a new filesystem storage.
+// The root path is made absolute so note paths can be confined to it.
 func NewFileSystemStorage(rootPath string) *FileSystemStorage {
+	if abs, err := filepath.Abs(rootPath); err == nil {
+		rootPath = abs
+	}
 	return &FileSystemStorage{
 		rootPath: rootPath,
+		settings: DefaultVaultSettings(),
 	}
 }
 
-// Initialize creates the root directory if it doesn't exist
+// Initialize creates the root directory if it doesn't exist and loads the vault settings
 func (fs *FileSystemStorage) Initialize() error {
-	return os.MkdirAll(fs.rootPath, 0755)
+	if err := os.MkdirAll(fs.rootPath, 0755); err != nil {
+		return err
+	}
+
+	settings, err := LoadVaultSettings(fs.rootPath)
+	if err != nil {
+		return err
+	}
+	if fs.defaultExtension != "" {
+		settings.DefaultExtension = normalizeExtension(fs.defaultExtension)
+		if !settings.HasNoteExtension("x" + settings.DefaultExtension) {
+			settings.Extensions = append(settings.Extensions, settings.DefaultExtension)
+		}
+	}
+	fs.settings = settings
+	return nil
 }
 
-// GetAllNotes returns all notes in the storage
-func (fs *FileSystemStorage) GetAllNotes() ([]*note.Note, error) {
-	logger.Debug("Getting all notes from directory: %s", fs.rootPath)
-	var notes []*note.Note
+// SetDefaultExtension overrides the vault's extension for new notes.
+// It must be called before Initialize.
+func (fs *FileSystemStorage) SetDefaultExtension(ext string) {
+	fs.defaultExtension = ext
+}
 
-	err := filepath.Walk(fs.rootPath, func(path string, info os.FileInfo, err error) error {
+// GetAllNotes returns all notes in the storage. The directory is walked
+// first and the notes are then loaded in parallel by a bounded pool of workers.
+// Notes and folders that cannot be read are returned as problems.
+// Hidden folders such as .git and the quarantine folder are skipped.
+func (fs *FileSystemStorage) GetAllNotes(ctx context.Context) ([]*note.Note, []LoadProblem, error) {
+	log.Debug("getting all notes", "root", fs.rootPath)
+
+	var paths []string
+	var problems []LoadProblem
+	err := filepath.WalkDir(fs.rootPath, func(path string, d iofs.DirEntry, err error) error {
 		if err != nil {
+			if path == fs.rootPath {
+				return err
+			}
+			problems = append(problems, newLoadProblem(path, err))
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
 			return err
 		}
 
-		if !info.IsDir() && strings.HasSuffix(path, ".note") {
-			note, err := note.LoadNote(path)
-			if err != nil {
-				fmt.Printf("Warning: failed to load note %s: %v\n", path, err)
-				return nil // Continue walking
+		if d.IsDir() {
+			if path != fs.rootPath && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
 			}
-			notes = append(notes, note)
+			return nil
+		}
+
+		if fs.settings.HasNoteExtension(path) {
+			paths = append(paths, path)
 		}
 
 		return nil
 	})
 
 	if err != nil {
-		logger.Debug("Error getting all notes: %v", err)
-		return nil, fmt.Errorf("failed to walk directory: %w", err)
+		log.Error("failed to walk vault", "root", fs.rootPath, "err", err)
+		return nil, nil, fmt.Errorf("failed to walk directory: %w", err)
 	}
 
-	logger.Debug("Successfully retrieved %d notes", len(notes))
-	return notes, nil
+	// Each worker writes to its own slot, so results keep the walk order
+	loaded := make([]*note.Note, len(paths))
+	failed := make([]error, len(paths))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(runtime.GOMAXPROCS(0))
+
+	for i, path := range paths {
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+
+			n, err := note.LoadNoteInfo(path, fs.settings.MetadataFormat)
+			if err != nil {
+				log.Warn("failed to load note", "path", path, "err", err)
+				failed[i] = err
+				return nil // Continue loading the others
+			}
+			loaded[i] = n
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		log.Warn("loading notes stopped", "err", err)
+		return nil, nil, fmt.Errorf("failed to load notes: %w", err)
+	}
+
+	notes := make([]*note.Note, 0, len(loaded))
+	for i, n := range loaded {
+		if n != nil {
+			notes = append(notes, n)
+		} else if failed[i] != nil {
+			problems = append(problems, newLoadProblem(paths[i], failed[i]))
+		}
+	}
+
+	log.Debug("retrieved notes", "count", len(notes), "problems", len(problems))
+	return notes, problems, nil
 }
 
 // GetNote loads a specific note by path
 func (fs *FileSystemStorage) GetNote(notePath string) (*note.Note, error) {
-	logger.Debug("Getting note: %s", notePath)
+	log.Debug("getting note", "path", notePath)
 
-	// If notePath already starts with rootPath, don't join them
-	var fullPath string
-	if strings.HasPrefix(notePath, fs.rootPath) {
-		fullPath = notePath
-	} else {
-		fullPath = filepath.Join(fs.rootPath, notePath)
-	}
-
-	if !strings.HasSuffix(fullPath, ".note") {
-		fullPath += ".note"
+	fullPath, err := fs.resolve(notePath)
+	if err != nil {
+		return nil, err
 	}
+	fullPath = fs.resolveExisting(fullPath)
 
-	note, err := note.LoadNote(fullPath)
+	note, err := note.LoadNote(fullPath, fs.settings.MetadataFormat)
 	if err != nil {
-		logger.Debug("Error getting note %s: %v", notePath, err)
+		log.Debug("failed to get note", "path", notePath, "err", err)
+		if errors.Is(err, iofs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, notePath)
+		}
 		return nil, err
 	}
 
-	logger.Debug("Successfully retrieved note: %s", notePath)
+	log.Debug("retrieved note", "path", notePath)
 	return note, nil
 }
 
 // CreateNote creates a new note
 func (fs *FileSystemStorage) CreateNote(notePath, content string) (*note.Note, error) {
-	logger.Debug("Creating new note: %s", notePath)
+	log.Debug("creating note", "path", notePath)
 
-	// If notePath already starts with rootPath, don't join them
-	var fullPath string
-	if strings.HasPrefix(notePath, fs.rootPath) {
-		fullPath = notePath
-	} else {
-		fullPath = filepath.Join(fs.rootPath, notePath)
+	fullPath, err := fs.resolve(notePath)
+	if err != nil {
+		return nil, err
 	}
-
-	if !strings.HasSuffix(fullPath, ".note") {
-		fullPath += ".note"
+	if !fs.settings.HasNoteExtension(fullPath) {
+		fullPath += fs.settings.DefaultExtension
 	}
 
 	// Check if note already exists
 	if _, err := os.Stat(fullPath); err == nil {
-		logger.Debug("Note already exists: %s", notePath)
-		return nil, fmt.Errorf("note already exists: %s", notePath)
+		log.Debug("note already exists", "path", notePath)
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyExists, notePath)
 	}
 
 	newNote := note.NewNote(fullPath)
 	newNote.Content = content
+	newNote.Format = fs.settings.MetadataFormat
 
 	if err := newNote.Save(); err != nil {
-		logger.Debug("Error creating note %s: %v", notePath, err)
+		log.Error("failed to create note", "path", notePath, "err", err)
 		return nil, fmt.Errorf("failed to save new note: %w", err)
 	}
 
-	logger.Debug("Successfully created note: %s", notePath)
+	log.Info("created note", "path", notePath)
 	return newNote, nil
 }
 
+// LoadContent reads a note's content from disk
+func (fs *FileSystemStorage) LoadContent(n *note.Note) error {
+	if err := n.LoadContent(); err != nil {
+		if errors.Is(err, iofs.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrNotFound, n.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// SaveNote writes a note's content and metadata
+func (fs *FileSystemStorage) SaveNote(n *note.Note) error {
+	log.Debug("saving note", "path", n.Path)
+	return n.Write()
+}
+
+// DeleteNote removes a note and its metadata
+func (fs *FileSystemStorage) DeleteNote(notePath string) error {
+	log.Info("deleting note", "path", notePath)
+
+	n, err := fs.GetNote(notePath)
+	if err != nil {
+		return err
+	}
+	return n.Delete()
+}
+
+// RenameNote renames a note file and its sidecar metadata, keeping the extension
+func (fs *FileSystemStorage) RenameNote(notePath, newName string) (*note.Note, error) {
+	log.Info("renaming note", "path", notePath, "name", newName)
+
+	if err := validateNewName(newName); err != nil {
+		return nil, err
+	}
+
+	n, err := fs.GetNote(notePath)
+	if err != nil {
+		return nil, err
+	}
+
+	newPath := filepath.Join(filepath.Dir(n.Path), newName+filepath.Ext(n.Path))
+	if _, err := os.Stat(newPath); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyExists, newName)
+	}
+
+	oldMetaPath := n.GetMetaPath()
+	if err := os.Rename(n.Path, newPath); err != nil {
+		return nil, fmt.Errorf("failed to rename note: %w", err)
+	}
+	if err := os.Rename(oldMetaPath, note.MetaPathFor(newPath)); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to rename metadata file: %w", err)
+	}
+
+	return note.LoadNote(newPath, fs.settings.MetadataFormat)
+}
+
+// MoveNote moves a note and its metadata file into a folder of the vault,
+// creating the folder if needed
+func (fs *FileSystemStorage) MoveNote(notePath, folder string) (*note.Note, error) {
+	log.Info("moving note", "path", notePath, "folder", folder)
+
+	dir := fs.rootPath
+	if folder = strings.Trim(folder, "/"); folder != "" {
+		var err error
+		if dir, err = fs.resolve(folder); err != nil {
+			return nil, err
+		}
+	}
+
+	n, err := fs.GetNote(notePath)
+	if err != nil {
+		return nil, err
+	}
+
+	newPath := filepath.Join(dir, filepath.Base(n.Path))
+	if newPath == n.Path {
+		return n, nil
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyExists, filepath.Join(folder, n.Name))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	oldMetaPath := n.GetMetaPath()
+	if err := os.Rename(n.Path, newPath); err != nil {
+		return nil, fmt.Errorf("failed to move note: %w", err)
+	}
+	if err := os.Rename(oldMetaPath, note.MetaPathFor(newPath)); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to move metadata file: %w", err)
+	}
+
+	return note.LoadNote(newPath, fs.settings.MetadataFormat)
+}
+
+// resolveExisting finds the file for a path given without a note extension
+// by trying each recognized extension in order
+func (fs *FileSystemStorage) resolveExisting(fullPath string) string {
+	if fs.settings.HasNoteExtension(fullPath) {
+		return fullPath
+	}
+
+	for _, ext := range fs.settings.Extensions {
+		if _, err := os.Stat(fullPath + ext); err == nil {
+			return fullPath + ext
+		}
+	}
+	return fullPath + fs.settings.DefaultExtension
+}
+
 // GetRootPath returns the root path of the storage
 func (fs *FileSystemStorage) GetRootPath() string {
 	return fs.rootPath
 }
+
+// Settings returns the vault settings
+func (fs *FileSystemStorage) Settings() *VaultSettings {
+	return fs.settings
+}
+
+// MetadataFormat returns the metadata layout used by the vault
+func (fs *FileSystemStorage) MetadataFormat() note.MetadataFormat {
+	return fs.settings.MetadataFormat
+}
+
+// MigrateMetadataFormat converts every note in the vault to the given
+// metadata layout and records it in the vault settings.
+// It returns the number of converted notes.
+//
+// The vault reads correctly in its recorded layout after every step:
+// sidecars are written before notes lose their front matter, and only
+// removed once every note has front matter and the new layout is saved.
+// If rewriting the notes or saving the layout fails, the notes already
+// rewritten are restored.
+func (fs *FileSystemStorage) MigrateMetadataFormat(format note.MetadataFormat) (int, error) {
+	log.Info("migrating metadata", "from", fs.settings.MetadataFormat, "to", format)
+
+	// Load everything before writing so a parse error leaves the vault untouched
+	notes, problems, err := fs.GetAllNotes(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	if len(problems) > 0 {
+		return 0, fmt.Errorf("%d notes could not be loaded, fix them before migrating (first: %s: %v)",
+			len(problems), problems[0].Path, problems[0].Err)
+	}
+
+	// Read every body with the old layout before anything changes
+	for _, n := range notes {
+		if err := n.LoadContent(); err != nil {
+			return 0, fmt.Errorf("failed to read note %s: %w", n.Path, err)
+		}
+	}
+
+	if format == note.FormatSidecar {
+		// Sidecars are ignored while a note has front matter, so writing
+		// them first changes nothing yet
+		for _, n := range notes {
+			if err := n.Metadata.SaveMetadata(n.GetMetaPath()); err != nil {
+				return 0, fmt.Errorf("failed to convert note %s: %w", n.Path, err)
+			}
+		}
+	}
+
+	originals := make(map[string][]byte, len(notes))
+	restore := func() {
+		for path, data := range originals {
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				log.Error("failed to restore note after a failed migration", "path", path, "err", err)
+			}
+		}
+	}
+	for _, n := range notes {
+		data, err := os.ReadFile(n.Path)
+		if err != nil {
+			restore()
+			return 0, fmt.Errorf("failed to convert note %s: %w", n.Path, err)
+		}
+		originals[n.Path] = data
+
+		n.Format = format
+		if err := n.WriteFile(); err != nil {
+			restore()
+			return 0, fmt.Errorf("failed to convert note %s: %w", n.Path, err)
+		}
+	}
+
+	previous := fs.settings.MetadataFormat
+	fs.settings.MetadataFormat = format
+	if err := fs.settings.Save(fs.rootPath); err != nil {
+		fs.settings.MetadataFormat = previous
+		restore()
+		return 0, err
+	}
+
+	if format == note.FormatFrontMatter {
+		// Every note carries its metadata now; a sidecar that cannot be
+		// removed is ignored, so the vault stays readable either way
+		var errs []error
+		for _, n := range notes {
+			if err := os.Remove(n.GetMetaPath()); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			return len(notes), fmt.Errorf("converted every note but failed to remove old metadata files: %w", errors.Join(errs...))
+		}
+	}
+
+	log.Info("migrated metadata", "notes", len(notes), "format", format)
+	return len(notes), nil
+}
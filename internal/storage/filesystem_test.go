@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"notes-app/internal/note"
+)
+
+// newTestVault creates a filesystem vault with a few notes in a temp directory
+func newTestVault(t *testing.T) *FileSystemStorage {
+	t.Helper()
+	fs := NewFileSystemStorage(t.TempDir())
+	if err := fs.Initialize(); err != nil {
+		t.Fatalf("failed to initialize vault: %v", err)
+	}
+
+	for _, name := range []string{"todo", "work/plan", "v1.2"} {
+		n, err := fs.CreateNote(name, "# "+name+"\n\nbody of "+name+"\n")
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		n.Metadata.Tags = []string{"tag-" + filepath.Base(name)}
+		n.Metadata.Title = "Title " + name
+		if err := fs.SaveNote(n); err != nil {
+			t.Fatalf("failed to save %s: %v", name, err)
+		}
+	}
+	return fs
+}
+
+// vaultFiles returns the contents of every file in the vault by relative path
+func vaultFiles(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read vault: %v", err)
+	}
+	return files
+}
+
+// noteSnapshot is what a note must keep across a migration
+type noteSnapshot struct {
+	content string
+	title   string
+	tags    []string
+	created time.Time
+	updated time.Time
+}
+
+// snapshotNotes loads every note of the vault with its content
+func snapshotNotes(t *testing.T, fs *FileSystemStorage) map[string]noteSnapshot {
+	t.Helper()
+	notes, problems, err := fs.GetAllNotes(context.Background())
+	if err != nil || len(problems) > 0 {
+		t.Fatalf("failed to load notes: %v %v", err, problems)
+	}
+
+	snapshots := make(map[string]noteSnapshot)
+	for _, n := range notes {
+		if err := fs.LoadContent(n); err != nil {
+			t.Fatalf("failed to load %s: %v", n.Path, err)
+		}
+		snapshots[n.Path] = noteSnapshot{
+			content: n.Content,
+			title:   n.Metadata.Title,
+			tags:    n.Metadata.Tags,
+			created: n.Metadata.Created,
+			updated: n.Metadata.Updated,
+		}
+	}
+	return snapshots
+}
+
+// metaFiles returns the sidecar files in the vault
+func metaFiles(files map[string]string) []string {
+	var metas []string
+	for path := range files {
+		if filepath.Ext(path) == ".meta" {
+			metas = append(metas, path)
+		}
+	}
+	slices.Sort(metas)
+	return metas
+}
+
+func checkSnapshots(t *testing.T, got, want map[string]noteSnapshot) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d notes, want %d", len(got), len(want))
+	}
+	for path, w := range want {
+		g, ok := got[path]
+		switch {
+		case !ok:
+			t.Errorf("%s: missing", path)
+		case g.content != w.content:
+			t.Errorf("%s: content = %q, want %q", path, g.content, w.content)
+		case g.title != w.title || !slices.Equal(g.tags, w.tags):
+			t.Errorf("%s: title %q tags %v, want %q %v", path, g.title, g.tags, w.title, w.tags)
+		case !g.created.Equal(w.created) || !g.updated.Equal(w.updated):
+			t.Errorf("%s: timestamps %v %v, want %v %v", path, g.created, g.updated, w.created, w.updated)
+		}
+	}
+}
+
+func TestMigrateMetadataFormatRoundTrip(t *testing.T) {
+	fs := newTestVault(t)
+	want := snapshotNotes(t, fs)
+
+	converted, err := fs.MigrateMetadataFormat(note.FormatFrontMatter)
+	if err != nil || converted != len(want) {
+		t.Fatalf("migrating to front matter = %d, %v", converted, err)
+	}
+	if metas := metaFiles(vaultFiles(t, fs.GetRootPath())); len(metas) > 0 {
+		t.Errorf("sidecars left after migrating to front matter: %v", metas)
+	}
+
+	// A fresh storage must read the vault with the saved layout
+	reopened := NewFileSystemStorage(fs.GetRootPath())
+	if err := reopened.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if reopened.settings.MetadataFormat != note.FormatFrontMatter {
+		t.Errorf("saved format = %s, want %s", reopened.settings.MetadataFormat, note.FormatFrontMatter)
+	}
+	checkSnapshots(t, snapshotNotes(t, reopened), want)
+
+	converted, err = reopened.MigrateMetadataFormat(note.FormatSidecar)
+	if err != nil || converted != len(want) {
+		t.Fatalf("migrating to sidecars = %d, %v", converted, err)
+	}
+	if metas := metaFiles(vaultFiles(t, fs.GetRootPath())); len(metas) != len(want) {
+		t.Errorf("sidecars after migrating back = %v, want %d", metas, len(want))
+	}
+	checkSnapshots(t, snapshotNotes(t, reopened), want)
+}
+
+func TestMigrateMetadataFormatFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   note.MetadataFormat
+		to     note.MetadataFormat
+		breaks func(t *testing.T, fs *FileSystemStorage)
+	}{
+		{
+			name: "settings cannot be saved",
+			from: note.FormatSidecar,
+			to:   note.FormatFrontMatter,
+			breaks: func(t *testing.T, fs *FileSystemStorage) {
+				if err := os.Mkdir(filepath.Join(fs.GetRootPath(), VaultSettingsFile), 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "sidecar cannot be written",
+			from: note.FormatFrontMatter,
+			to:   note.FormatSidecar,
+			breaks: func(t *testing.T, fs *FileSystemStorage) {
+				if err := os.Mkdir(note.MetaPathFor(filepath.Join(fs.GetRootPath(), "todo.note")), 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newTestVault(t)
+			if tt.from != fs.settings.MetadataFormat {
+				if _, err := fs.MigrateMetadataFormat(tt.from); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want := snapshotNotes(t, fs)
+			files := vaultFiles(t, fs.GetRootPath())
+
+			tt.breaks(t, fs)
+			converted, err := fs.MigrateMetadataFormat(tt.to)
+			if err == nil || converted != 0 {
+				t.Fatalf("MigrateMetadataFormat = %d, %v, want an error", converted, err)
+			}
+
+			if fs.settings.MetadataFormat != tt.from {
+				t.Errorf("format = %s, want %s", fs.settings.MetadataFormat, tt.from)
+			}
+			after := vaultFiles(t, fs.GetRootPath())
+			for path, data := range files {
+				if path == VaultSettingsFile {
+					continue
+				}
+				if after[path] != data {
+					t.Errorf("%s changed by a failed migration:\n%s", path, after[path])
+				}
+			}
+			checkSnapshots(t, snapshotNotes(t, fs), want)
+		})
+	}
+}
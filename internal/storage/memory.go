@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"notes-app/internal/note"
+)
+
+// MemoryRootPath is the root path reported by MemoryStorage
+const MemoryRootPath = "memory:"
+
+// MemoryStorage keeps notes in memory only. It is used for embedding the
+// notes engine in other tools and for tests. Note paths are note names,
+// e.g. "work/todo", and every note is copied on the way in and out so
+// callers cannot change stored notes without saving them.
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	notes   map[string]*note.Note
+	seedDir string
+
+	// skipped are the fixture notes that could not be seeded
+	skipped []LoadProblem
+}
+
+// NewMemoryStorage creates an in-memory storage. If seedDir is not empty,
+// Initialize loads the notes of the filesystem vault at seedDir; the
+// directory itself is never written to.
+func NewMemoryStorage(seedDir string) *MemoryStorage {
+	return &MemoryStorage{
+		notes:   make(map[string]*note.Note),
+		seedDir: seedDir,
+	}
+}
+
+// Initialize seeds the storage from the fixture directory, if any
+func (ms *MemoryStorage) Initialize() error {
+	if ms.seedDir == "" {
+		return nil
+	}
+
+	fixture := NewFileSystemStorage(ms.seedDir)
+	if err := fixture.Initialize(); err != nil {
+		return fmt.Errorf("failed to open fixture directory: %w", err)
+	}
+
+	copied, skipped, err := Copy(context.Background(), fixture, ms)
+	if err != nil {
+		return fmt.Errorf("failed to seed memory storage: %w", err)
+	}
+	ms.skipped = skipped
+
+	log.Debug("seeded memory storage", "notes", copied, "skipped", len(skipped), "dir", ms.seedDir)
+	return nil
+}
+
+// GetAllNotes returns copies of all notes. Fixture notes that could not
+// be seeded are reported as problems.
+func (ms *MemoryStorage) GetAllNotes(ctx context.Context) ([]*note.Note, []LoadProblem, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	notes := make([]*note.Note, 0, len(ms.notes))
+	for _, n := range ms.notes {
+		notes = append(notes, n.Clone())
+	}
+	return notes, append([]LoadProblem(nil), ms.skipped...), nil
+}
+
+// GetNote returns a copy of a note by name
+func (ms *MemoryStorage) GetNote(notePath string) (*note.Note, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	n, ok := ms.notes[memoryKey(notePath)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, notePath)
+	}
+	return n.Clone(), nil
+}
+
+// LoadContent fills in the content of a note
+func (ms *MemoryStorage) LoadContent(n *note.Note) error {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	stored, ok := ms.notes[memoryKey(n.Path)]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, n.Path)
+	}
+	n.Content = stored.Content
+	n.ContentLoaded = true
+	return nil
+}
+
+// CreateNote creates a new note
+func (ms *MemoryStorage) CreateNote(notePath, content string) (*note.Note, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	name := memoryName(notePath)
+	if err := ValidateNoteName(name); err != nil {
+		return nil, err
+	}
+	if _, exists := ms.notes[memoryKey(name)]; exists {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyExists, notePath)
+	}
+
+	now := time.Now()
+	n := newMemoryNote(name)
+	n.Content = content
+	n.Metadata.Touch(now)
+	n.ModTime = now
+	n.Size = int64(len(content))
+
+	ms.notes[memoryKey(name)] = n.Clone()
+	return n, nil
+}
+
+// SaveNote stores a copy of the note
+func (ms *MemoryStorage) SaveNote(n *note.Note) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if !n.ContentLoaded {
+		if stored, ok := ms.notes[memoryKey(n.Path)]; ok {
+			n.Content = stored.Content
+		}
+		n.ContentLoaded = true
+	}
+
+	n.ModTime = time.Now()
+	n.Size = int64(len(n.Content))
+
+	stored := n.Clone()
+	stored.Path = memoryName(n.Path)
+	ms.notes[memoryKey(stored.Path)] = stored
+	return nil
+}
+
+// DeleteNote removes a note
+func (ms *MemoryStorage) DeleteNote(notePath string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	key := memoryKey(notePath)
+	if _, ok := ms.notes[key]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, notePath)
+	}
+	delete(ms.notes, key)
+	return nil
+}
+
+// RenameNote renames a note, keeping it in the same folder
+func (ms *MemoryStorage) RenameNote(notePath, newName string) (*note.Note, error) {
+	if err := validateNewName(newName); err != nil {
+		return nil, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	oldKey := memoryKey(notePath)
+	n, ok := ms.notes[oldKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, notePath)
+	}
+
+	target := newName
+	if dir := path.Dir(n.Path); dir != "." {
+		target = path.Join(dir, newName)
+	}
+	newKey := memoryKey(target)
+	if _, exists := ms.notes[newKey]; exists && newKey != oldKey {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyExists, newName)
+	}
+
+	renamed := newMemoryNote(target)
+	renamed.Content = n.Content
+	renamed.Metadata = n.Metadata
+	renamed.ModTime = n.ModTime
+	renamed.Size = n.Size
+
+	delete(ms.notes, oldKey)
+	ms.notes[newKey] = renamed
+	return renamed.Clone(), nil
+}
+
+// MoveNote moves a note into another folder, keeping its name
+func (ms *MemoryStorage) MoveNote(notePath, folder string) (*note.Note, error) {
+	if err := validateFolder(folder); err != nil {
+		return nil, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	oldKey := memoryKey(notePath)
+	n, ok := ms.notes[oldKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, notePath)
+	}
+
+	target := path.Join(strings.Trim(folder, "/"), n.Name)
+	newKey := memoryKey(target)
+	if newKey == oldKey {
+		return n.Clone(), nil
+	}
+	if _, exists := ms.notes[newKey]; exists {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyExists, target)
+	}
+
+	moved := newMemoryNote(target)
+	moved.Content = n.Content
+	moved.Metadata = n.Metadata
+	moved.ModTime = n.ModTime
+	moved.Size = n.Size
+
+	delete(ms.notes, oldKey)
+	ms.notes[newKey] = moved
+	return moved.Clone(), nil
+}
+
+// GetRootPath returns a placeholder root path
+func (ms *MemoryStorage) GetRootPath() string {
+	return MemoryRootPath
+}
+
+// memoryName converts a path or name to the stored note name
+func memoryName(notePath string) string {
+	return strings.Trim(strings.TrimPrefix(notePath, MemoryRootPath), "/")
+}
+
+// memoryKey returns the case-insensitive map key for a note
+func memoryKey(notePath string) string {
+	return strings.ToLower(memoryName(notePath))
+}
+
+// newMemoryNote creates a note whose path is its name
+func newMemoryNote(name string) *note.Note {
+	n := note.NewNote(name)
+	n.Name = path.Base(name)
+	return n
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"notes-app/internal/note"
+)
+
+func TestMemoryStorageSeed(t *testing.T) {
+	fixture := newTestVault(t)
+	broken := filepath.Join(fixture.GetRootPath(), "broken.note")
+	if err := os.WriteFile(broken, []byte("broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(note.MetaPathFor(broken), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := vaultFiles(t, fixture.GetRootPath())
+
+	ms := NewMemoryStorage(fixture.GetRootPath())
+	if err := ms.Initialize(); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	notes, problems, err := ms.GetAllNotes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, n := range notes {
+		names = append(names, n.Path)
+	}
+	slices.Sort(names)
+	if want := []string{"todo", "v1.2", "work/plan"}; !slices.Equal(names, want) {
+		t.Errorf("seeded notes = %v, want %v", names, want)
+	}
+	if len(problems) != 1 || problems[0].Path != broken || problems[0].Kind != ProblemInvalidMetadata {
+		t.Errorf("problems = %v, want the broken note", problems)
+	}
+
+	n, err := ms.GetNote("v1.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Content != "# v1.2\n\nbody of v1.2\n" || !slices.Equal(n.Metadata.Tags, []string{"tag-v1.2"}) {
+		t.Errorf("seeded v1.2 = %q %v", n.Content, n.Metadata.Tags)
+	}
+
+	// Changing the memory vault must never touch the fixture
+	n.Content = "changed"
+	n.Metadata.Tags = []string{"changed"}
+	if err := ms.SaveNote(n); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.RenameNote("todo", "done"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.DeleteNote("work/plan"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.CreateNote("new", "new note"); err != nil {
+		t.Fatal(err)
+	}
+
+	after := vaultFiles(t, fixture.GetRootPath())
+	if len(after) != len(files) {
+		t.Errorf("fixture has %d files after changes, want %d", len(after), len(files))
+	}
+	for path, data := range files {
+		if after[path] != data {
+			t.Errorf("fixture file %s changed:\n%s", path, after[path])
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// MaxNameLength is the longest allowed file name of a note in bytes,
+// leaving room for the note and .meta extensions
+const MaxNameLength = 200
+
+// reservedNames cannot be used as file names on Windows, with or without an extension
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// NameError describes why a note name cannot be used
+type NameError struct {
+	Name   string
+	Reason string
+}
+
+func (e *NameError) Error() string {
+	return fmt.Sprintf("invalid note name %q: %s", e.Name, e.Reason)
+}
+
+// ValidateNoteName checks that a note name is safe to use as a path inside
+// a vault. Names may contain folders separated by '/', but no part may
+// leave the vault, be hidden, or be a name that some filesystems reject.
+func ValidateNoteName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return &NameError{Name: name, Reason: "name cannot be empty"}
+	}
+	if strings.HasPrefix(name, "/") || filepath.IsAbs(name) {
+		return &NameError{Name: name, Reason: "name cannot be an absolute path"}
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if err := validateNamePart(part); err != "" {
+			return &NameError{Name: name, Reason: err}
+		}
+	}
+	return nil
+}
+
+// validateNewName checks the new name of a renamed note, which stays in its folder
+func validateNewName(name string) error {
+	if err := ValidateNoteName(name); err != nil {
+		return err
+	}
+	if strings.Contains(name, "/") {
+		return &NameError{Name: name, Reason: "renaming cannot move a note to another folder"}
+	}
+	return nil
+}
+
+// validateFolder checks the folder a note is moved to, "" being the top of the vault
+func validateFolder(folder string) error {
+	if folder = strings.Trim(folder, "/"); folder == "" {
+		return nil
+	}
+	return ValidateNoteName(folder)
+}
+
+// validateNamePart checks a single folder or file name and returns why it is invalid
+func validateNamePart(part string) string {
+	switch {
+	case part == "":
+		return "folder names cannot be empty"
+	case part == "." || part == "..":
+		return "'.' and '..' are not allowed"
+	case strings.HasPrefix(part, "."):
+		return "names cannot start with a dot"
+	case strings.HasSuffix(part, ".") || strings.HasSuffix(part, " "):
+		return "names cannot end with a dot or a space"
+	case len(part) > MaxNameLength:
+		return fmt.Sprintf("names cannot be longer than %d bytes", MaxNameLength)
+	}
+
+	for _, r := range part {
+		if unicode.IsControl(r) {
+			return "names cannot contain control characters"
+		}
+		if strings.ContainsRune(`\:*?"<>|`, r) {
+			return fmt.Sprintf("names cannot contain '%c'", r)
+		}
+	}
+
+	base := strings.ToUpper(part)
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+	if reservedNames[base] {
+		return fmt.Sprintf("'%s' is a reserved name", part)
+	}
+
+	return ""
+}
+
+// resolve turns a note path given by a caller into a cleaned absolute path
+// inside the vault. Relative paths are validated as note names; absolute
+// paths must already point inside the vault. Symbolic links that lead out
+// of the vault are rejected.
+func (fs *FileSystemStorage) resolve(notePath string) (string, error) {
+	var path string
+	if filepath.IsAbs(notePath) {
+		path = filepath.Clean(notePath)
+	} else {
+		if err := ValidateNoteName(filepath.ToSlash(notePath)); err != nil {
+			return "", err
+		}
+		path = filepath.Join(fs.rootPath, notePath)
+	}
+
+	if err := fs.confine(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// confine checks that a path stays inside the vault, both lexically and
+// after following symbolic links
+func (fs *FileSystemStorage) confine(path string) error {
+	root, err := filepath.Abs(fs.rootPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve vault path: %w", err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if !within(root, abs) {
+		return fmt.Errorf("path %s is outside the vault", path)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		// The vault does not exist yet, so nothing inside it can be a link
+		return nil
+	}
+	real, err := evalExisting(abs)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path: %w", err)
+	}
+	if !within(realRoot, real) {
+		return fmt.Errorf("path %s leads outside the vault", path)
+	}
+	return nil
+}
+
+// within reports whether path is root or inside it. Both must be clean and absolute.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// evalExisting follows symbolic links in the part of a path that exists
+// and appends the rest unchanged
+func evalExisting(path string) (string, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return real, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+	real, err = evalExisting(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(real, filepath.Base(path)), nil
+}
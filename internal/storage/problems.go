@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"notes-app/internal/note"
+)
+
+// ProblemKind classifies why a note could not be loaded or copied
+type ProblemKind string
+
+const (
+	ProblemUnreadable      ProblemKind = "unreadable"
+	ProblemInvalidMetadata ProblemKind = "invalid metadata"
+	ProblemPermission      ProblemKind = "permission denied"
+	ProblemNameTaken       ProblemKind = "name taken"
+)
+
+// QuarantineDir is the folder inside a vault that broken notes are moved to
+const QuarantineDir = ".quarantine"
+
+// LoadProblem describes a note that was skipped while loading the vault
+type LoadProblem struct {
+	// Path identifies the note
+	Path string
+	// File is the file that caused the problem, e.g. the .meta file
+	File string
+	Kind ProblemKind
+	Err  error
+}
+
+// newLoadProblem classifies a load error for a note
+func newLoadProblem(notePath string, err error) LoadProblem {
+	problem := LoadProblem{
+		Path: notePath,
+		File: notePath,
+		Kind: ProblemUnreadable,
+		Err:  err,
+	}
+
+	var metaErr *note.MetadataError
+	switch {
+	case errors.Is(err, iofs.ErrPermission):
+		problem.Kind = ProblemPermission
+	case errors.As(err, &metaErr):
+		problem.Kind = ProblemInvalidMetadata
+		problem.File = metaErr.Path
+	}
+
+	return problem
+}
+
+// ProblemFixer is implemented by storage backends that can repair or
+// set aside notes that failed to load
+type ProblemFixer interface {
+	// RepairNote resets broken metadata, keeping a backup of the original
+	RepairNote(problem LoadProblem) error
+	// QuarantineNote moves a broken note out of the vault's note list
+	QuarantineNote(problem LoadProblem) error
+}
+
+// RepairNote replaces unparsable metadata with fresh metadata. The broken
+// file is kept next to it with a .bak suffix.
+func (fs *FileSystemStorage) RepairNote(problem LoadProblem) error {
+	if problem.Kind != ProblemInvalidMetadata {
+		return fmt.Errorf("%s problems cannot be repaired automatically", problem.Kind)
+	}
+
+	info, err := os.Stat(problem.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read note: %w", err)
+	}
+
+	backup := problem.File + ".bak"
+	data, err := os.ReadFile(problem.File)
+	if err != nil {
+		return fmt.Errorf("failed to read broken metadata: %w", err)
+	}
+	if err := os.WriteFile(backup, data, 0644); err != nil {
+		return fmt.Errorf("failed to back up broken metadata: %w", err)
+	}
+
+	n := note.NewNote(problem.Path)
+	n.Metadata = note.NewMetadata(info.ModTime())
+
+	if problem.File == problem.Path {
+		// Broken front matter: keep everything as the body of the note
+		n.Format = note.FormatFrontMatter
+		n.Content = string(data)
+	} else {
+		n.Format = note.FormatSidecar
+		if err := n.LoadContent(); err != nil {
+			return err
+		}
+	}
+
+	log.Info("repaired metadata", "path", problem.Path, "backup", backup)
+	return n.Write()
+}
+
+// QuarantineNote moves a note and its sidecar into the vault's quarantine
+// folder, keeping its relative path. Quarantined notes are not listed.
+func (fs *FileSystemStorage) QuarantineNote(problem LoadProblem) error {
+	target, err := fs.quarantineTarget(problem.Path, true)
+	if err != nil {
+		return err
+	}
+	if err := moveToQuarantine(problem.Path, target); err != nil {
+		return err
+	}
+	if err := os.Rename(note.MetaPathFor(problem.Path), note.MetaPathFor(target)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to quarantine metadata: %w", err)
+	}
+
+	log.Info("quarantined note", "path", problem.Path, "target", target)
+	return nil
+}
+
+// QuarantineFile moves any file in the vault into the quarantine folder,
+// keeping its relative path, and returns its new location
+func (fs *FileSystemStorage) QuarantineFile(path string) (string, error) {
+	target, err := fs.quarantineTarget(path, false)
+	if err != nil {
+		return "", err
+	}
+	if err := moveToQuarantine(path, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// quarantineTarget returns where a file goes in the quarantine folder.
+// Earlier quarantined files are never overwritten: if the place is taken,
+// a timestamp is put before the extension so that a note and its sidecar
+// still belong together. With sidecar set, the note's sidecar must fit too.
+func (fs *FileSystemStorage) quarantineTarget(path string, sidecar bool) (string, error) {
+	if err := fs.confine(path); err != nil {
+		return "", fmt.Errorf("cannot quarantine %s: %w", path, err)
+	}
+	rel, err := filepath.Rel(fs.rootPath, path)
+	if err != nil {
+		return "", fmt.Errorf("cannot quarantine %s: %w", path, err)
+	}
+
+	taken := func(target string) bool {
+		if _, err := os.Lstat(target); err == nil {
+			return true
+		}
+		if !sidecar {
+			return false
+		}
+		_, err := os.Lstat(note.MetaPathFor(target))
+		return err == nil
+	}
+
+	target := filepath.Join(fs.rootPath, QuarantineDir, rel)
+	if taken(target) {
+		ext := filepath.Ext(target)
+		base := strings.TrimSuffix(target, ext) + "." + time.Now().Format("20060102-150405")
+		target = base + ext
+		for i := 2; taken(target); i++ {
+			target = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+	}
+	return target, nil
+}
+
+// moveToQuarantine moves a file to its quarantine target, creating the folder
+func moveToQuarantine(path, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create quarantine folder: %w", err)
+	}
+	if err := os.Rename(path, target); err != nil {
+		return fmt.Errorf("failed to quarantine %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"notes-app/internal/note"
+)
+
+func TestQuarantineNoteKeepsPairs(t *testing.T) {
+	fs := NewFileSystemStorage(t.TempDir())
+	if err := fs.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	root := fs.GetRootPath()
+	write := func(name, data string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An orphaned sidecar quarantined earlier takes the sidecar's place
+	write("work/x.meta", "orphan")
+	if _, err := fs.QuarantineFile(filepath.Join(root, "work/x.meta")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same broken notes are quarantined several times
+	for i := range 3 {
+		for _, name := range []string{"work/x.note", "work/y.md"} {
+			write(name, fmt.Sprintf("note %d", i))
+			write(note.MetaPathFor(name), fmt.Sprintf("meta %d", i))
+
+			_, problems, err := fs.GetAllNotes(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var problem *LoadProblem
+			for _, p := range problems {
+				if filepath.Base(p.Path) == filepath.Base(name) {
+					problem = &p
+				}
+			}
+			if problem == nil {
+				t.Fatalf("%s not reported as a problem: %v", name, problems)
+			}
+			if err := fs.QuarantineNote(*problem); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Every quarantined note must have its own sidecar next to it
+	quarantine := filepath.Join(root, QuarantineDir, "work")
+	entries, err := os.ReadDir(quarantine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := 0
+	for _, e := range entries {
+		path := filepath.Join(quarantine, e.Name())
+		if filepath.Ext(path) == ".meta" {
+			continue
+		}
+		notes++
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta, err := os.ReadFile(note.MetaPathFor(path))
+		if err != nil {
+			t.Errorf("%s has no sidecar: %v", e.Name(), err)
+			continue
+		}
+		if want := strings.Replace(string(content), "note", "meta", 1); string(meta) != want {
+			t.Errorf("%s is paired with %q, want %q", e.Name(), meta, want)
+		}
+	}
+	if notes != 6 || len(entries) != 13 {
+		t.Errorf("quarantine holds %d notes in %d files, want 6 notes and 13 files", notes, len(entries))
+	}
+
+	orphan, err := os.ReadFile(filepath.Join(quarantine, "x.meta"))
+	if err != nil || string(orphan) != "orphan" {
+		t.Errorf("earlier quarantined sidecar = %q, %v", orphan, err)
+	}
+}
@@ -0,0 +1,415 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"notes-app/internal/note"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the tables used by SQLiteStorage
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS notes (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	name     TEXT NOT NULL UNIQUE COLLATE NOCASE,
+	content  TEXT NOT NULL,
+	metadata TEXT NOT NULL,
+	modified INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS revisions (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	note_id  INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+	content  TEXT NOT NULL,
+	metadata TEXT NOT NULL,
+	saved    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS revisions_note ON revisions(note_id);
+`
+
+// sqlitePragmas are set on every connection through the DSN, so they
+// still apply when database/sql opens a new connection
+const sqlitePragmas = "?_pragma=foreign_keys(1)"
+
+// maxRevisions is how many earlier versions of a note are kept
+const maxRevisions = 50
+
+// Revision is a previously saved version of a note
+type Revision struct {
+	Content  string
+	Metadata *note.Metadata
+	Saved    time.Time
+}
+
+// SQLiteStorage keeps notes, metadata and revisions in a single SQLite file.
+// Note paths are the note names relative to the vault, e.g. "work/todo".
+type SQLiteStorage struct {
+	dbPath string
+	db     *sql.DB
+}
+
+// NewSQLiteStorage creates a new SQLite storage for the database file
+func NewSQLiteStorage(dbPath string) *SQLiteStorage {
+	return &SQLiteStorage{
+		dbPath: dbPath,
+	}
+}
+
+// Initialize opens the database, creating the file and schema if needed
+func (s *SQLiteStorage) Initialize() error {
+	if err := os.MkdirAll(filepath.Dir(s.dbPath), 0755); err != nil {
+		return fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", s.dbPath+sqlitePragmas)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	// A single connection avoids lock contention
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to create database schema: %w", err)
+	}
+
+	s.db = db
+	return nil
+}
+
+// Close closes the database
+func (s *SQLiteStorage) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// GetAllNotes returns all notes in the database
+func (s *SQLiteStorage) GetAllNotes(ctx context.Context) ([]*note.Note, []LoadProblem, error) {
+	log.Debug("getting all notes", "db", s.dbPath)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT name, metadata, modified, length(CAST(content AS BLOB)) FROM notes`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []*note.Note
+	var problems []LoadProblem
+	for rows.Next() {
+		var name, meta string
+		var modified, size int64
+		if err := rows.Scan(&name, &meta, &modified, &size); err != nil {
+			return nil, nil, fmt.Errorf("failed to read note: %w", err)
+		}
+
+		n, err := decodeNote(name, meta, modified)
+		if err != nil {
+			log.Warn("failed to load note", "name", name, "err", err)
+			problems = append(problems, newLoadProblem(name, err))
+			continue
+		}
+		n.Size = size
+		n.ContentLoaded = false
+		notes = append(notes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read notes: %w", err)
+	}
+
+	log.Debug("retrieved notes", "count", len(notes), "problems", len(problems))
+	return notes, problems, nil
+}
+
+// GetNote loads a specific note by name
+func (s *SQLiteStorage) GetNote(notePath string) (*note.Note, error) {
+	log.Debug("getting note", "name", notePath)
+
+	row := s.db.QueryRow(`SELECT name, content, metadata, modified FROM notes WHERE name = ?`, s.noteName(notePath))
+	n, err := scanNote(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, notePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// LoadContent fetches a note's content from the database
+func (s *SQLiteStorage) LoadContent(n *note.Note) error {
+	var content string
+	err := s.db.QueryRow(`SELECT content FROM notes WHERE name = ?`, s.noteName(n.Path)).Scan(&content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrNotFound, n.Path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read note content: %w", err)
+	}
+
+	n.Content = content
+	n.ContentLoaded = true
+	return nil
+}
+
+// CreateNote creates a new note
+func (s *SQLiteStorage) CreateNote(notePath, content string) (*note.Note, error) {
+	meta := note.NewMetadata(time.Now())
+	return s.createNoteWithMetadata(notePath, content, meta)
+}
+
+// createNoteWithMetadata creates a new note with the given metadata in a
+// single write, so no revision of a note without its metadata is kept
+func (s *SQLiteStorage) createNoteWithMetadata(notePath, content string, meta *note.Metadata) (*note.Note, error) {
+	log.Info("creating note", "name", notePath)
+
+	name := s.noteName(notePath)
+	if err := ValidateNoteName(name); err != nil {
+		return nil, err
+	}
+	if exists, err := s.noteExists(name); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyExists, notePath)
+	}
+
+	newNote := newSQLiteNote(name)
+	newNote.Content = content
+	newNote.Metadata = meta
+
+	if err := s.SaveNote(newNote); err != nil {
+		return nil, fmt.Errorf("failed to save new note: %w", err)
+	}
+
+	return newNote, nil
+}
+
+// SaveNote writes a note and records the previous version as a revision
+func (s *SQLiteStorage) SaveNote(n *note.Note) error {
+	if !n.ContentLoaded {
+		if err := s.LoadContent(n); err != nil {
+			return err
+		}
+	}
+
+	meta, err := json.Marshal(n.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	modified := time.Now()
+	name := s.noteName(n.Path)
+
+	// Keep the current version before overwriting it
+	if _, err := tx.Exec(`INSERT INTO revisions (note_id, content, metadata, saved)
+		SELECT id, content, metadata, modified FROM notes WHERE name = ?`, name); err != nil {
+		return fmt.Errorf("failed to save revision: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM revisions WHERE note_id = (SELECT id FROM notes WHERE name = ?)
+		AND id NOT IN (SELECT r.id FROM revisions r JOIN notes n ON n.id = r.note_id
+			WHERE n.name = ? ORDER BY r.saved DESC, r.id DESC LIMIT ?)`, name, name, maxRevisions); err != nil {
+		return fmt.Errorf("failed to prune revisions: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO notes (name, content, metadata, modified) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET content = excluded.content, metadata = excluded.metadata, modified = excluded.modified`,
+		name, n.Content, string(meta), modified.UnixNano()); err != nil {
+		return fmt.Errorf("failed to save note: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit note: %w", err)
+	}
+
+	n.ModTime = modified
+	n.Size = int64(len(n.Content))
+	return nil
+}
+
+// DeleteNote removes a note and its revisions
+func (s *SQLiteStorage) DeleteNote(notePath string) error {
+	log.Info("deleting note", "name", notePath)
+
+	res, err := s.db.Exec(`DELETE FROM notes WHERE name = ?`, s.noteName(notePath))
+	if err != nil {
+		return fmt.Errorf("failed to delete note: %w", err)
+	}
+	if count, _ := res.RowsAffected(); count == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, notePath)
+	}
+	return nil
+}
+
+// RenameNote renames a note, keeping it in the same folder
+func (s *SQLiteStorage) RenameNote(notePath, newName string) (*note.Note, error) {
+	log.Info("renaming note", "name", notePath, "new_name", newName)
+
+	if err := validateNewName(newName); err != nil {
+		return nil, err
+	}
+
+	oldName := s.noteName(notePath)
+	target := newName
+	if dir := path.Dir(oldName); dir != "." {
+		target = path.Join(dir, newName)
+	}
+
+	if !strings.EqualFold(oldName, target) {
+		if exists, err := s.noteExists(target); err != nil {
+			return nil, err
+		} else if exists {
+			return nil, fmt.Errorf("%w: %s", ErrAlreadyExists, newName)
+		}
+	}
+
+	res, err := s.db.Exec(`UPDATE notes SET name = ? WHERE name = ?`, target, oldName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename note: %w", err)
+	}
+	if count, _ := res.RowsAffected(); count == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, notePath)
+	}
+
+	return s.GetNote(target)
+}
+
+// MoveNote moves a note into another folder, keeping its name
+func (s *SQLiteStorage) MoveNote(notePath, folder string) (*note.Note, error) {
+	log.Info("moving note", "name", notePath, "folder", folder)
+
+	if err := validateFolder(folder); err != nil {
+		return nil, err
+	}
+
+	oldName := s.noteName(notePath)
+	target := path.Join(strings.Trim(folder, "/"), path.Base(oldName))
+	if strings.EqualFold(oldName, target) {
+		return s.GetNote(oldName)
+	}
+	if exists, err := s.noteExists(target); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyExists, target)
+	}
+
+	res, err := s.db.Exec(`UPDATE notes SET name = ? WHERE name = ?`, target, oldName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move note: %w", err)
+	}
+	if count, _ := res.RowsAffected(); count == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, notePath)
+	}
+
+	return s.GetNote(target)
+}
+
+// noteExists reports whether a note with the name is stored. Database
+// errors are returned rather than taken to mean the note is not there.
+func (s *SQLiteStorage) noteExists(name string) (bool, error) {
+	var found int
+	err := s.db.QueryRow(`SELECT 1 FROM notes WHERE name = ?`, name).Scan(&found)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to look up note: %w", err)
+	}
+}
+
+// Revisions returns the saved revisions of a note, newest first
+func (s *SQLiteStorage) Revisions(notePath string) ([]Revision, error) {
+	rows, err := s.db.Query(`SELECT r.content, r.metadata, r.saved FROM revisions r
+		JOIN notes n ON n.id = r.note_id WHERE n.name = ? ORDER BY r.saved DESC`, s.noteName(notePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+	for rows.Next() {
+		var rev Revision
+		var meta string
+		var saved int64
+		if err := rows.Scan(&rev.Content, &meta, &saved); err != nil {
+			return nil, fmt.Errorf("failed to read revision: %w", err)
+		}
+		rev.Metadata = &note.Metadata{}
+		if err := json.Unmarshal([]byte(meta), rev.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to parse revision metadata: %w", err)
+		}
+		rev.Saved = time.Unix(0, saved)
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// GetRootPath returns the path of the database file
+func (s *SQLiteStorage) GetRootPath() string {
+	return s.dbPath
+}
+
+// noteName converts a path or name to the name stored in the database
+func (s *SQLiteStorage) noteName(notePath string) string {
+	name := filepath.ToSlash(strings.TrimPrefix(notePath, s.dbPath))
+	return strings.Trim(name, "/")
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanNote reads a note from a query result
+func scanNote(row rowScanner) (*note.Note, error) {
+	var name, content, meta string
+	var modified int64
+	if err := row.Scan(&name, &content, &meta, &modified); err != nil {
+		return nil, err
+	}
+
+	n, err := decodeNote(name, meta, modified)
+	if err != nil {
+		return nil, err
+	}
+	n.Content = content
+	n.Size = int64(len(content))
+	return n, nil
+}
+
+// decodeNote builds a note from its stored name, metadata and modification time
+func decodeNote(name, meta string, modified int64) (*note.Note, error) {
+	n := newSQLiteNote(name)
+	n.ModTime = time.Unix(0, modified)
+
+	if err := json.Unmarshal([]byte(meta), n.Metadata); err != nil {
+		return nil, &note.MetadataError{Path: name, Err: fmt.Errorf("failed to parse metadata JSON: %w", err)}
+	}
+	n.Metadata.Migrate(n.ModTime)
+
+	return n, nil
+}
+
+// newSQLiteNote creates a note whose path is its name in the database
+func newSQLiteNote(name string) *note.Note {
+	n := note.NewNote(name)
+	n.Name = path.Base(name)
+	return n
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"notes-app/internal/logger"
+	"notes-app/internal/note"
+)
+
+var log = logger.For("storage")
+
+// Storage is implemented by every note storage backend
+type Storage interface {
+	// Initialize prepares the backend for use, creating it if necessary
+	Initialize() error
+	// GetAllNotes returns all notes in the storage, stopping early if ctx is cancelled.
+	// Notes may be returned without content to keep memory use low.
+	// Notes that fail to load are skipped and reported as problems.
+	GetAllNotes(ctx context.Context) ([]*note.Note, []LoadProblem, error)
+	// GetNote loads a specific note by path or name, including its content
+	GetNote(notePath string) (*note.Note, error)
+	// LoadContent fetches the content of a note returned by GetAllNotes
+	LoadContent(n *note.Note) error
+	// CreateNote creates a new note and fails if it already exists
+	CreateNote(notePath, content string) (*note.Note, error)
+	// SaveNote writes a note's content and metadata as they are
+	SaveNote(n *note.Note) error
+	// DeleteNote removes a note and its metadata
+	DeleteNote(notePath string) error
+	// RenameNote gives a note a new name and returns it under its new path
+	RenameNote(notePath, newName string) (*note.Note, error)
+	// MoveNote moves a note into a folder, "" being the top of the vault,
+	// and returns it under its new path
+	MoveNote(notePath, folder string) (*note.Note, error)
+	// GetRootPath returns the location of the vault
+	GetRootPath() string
+}
+
+// Backend names used in the configuration
+const (
+	BackendFileSystem = "filesystem"
+	BackendSQLite     = "sqlite"
+	BackendMemory     = "memory"
+)
+
+// Backends lists the available storage backends
+var Backends = []string{BackendFileSystem, BackendSQLite, BackendMemory}
+
+// New creates a storage backend by name. The memory backend is seeded
+// from rootPath and never writes back to it.
+func New(backend, rootPath, sqlitePath string) (Storage, error) {
+	switch backend {
+	case BackendFileSystem, "":
+		return NewFileSystemStorage(rootPath), nil
+	case BackendSQLite:
+		return NewSQLiteStorage(sqlitePath), nil
+	case BackendMemory:
+		return NewMemoryStorage(rootPath), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend '%s' (available: %s)", backend, strings.Join(Backends, ", "))
+	}
+}
+
+// RelativeName returns a note's name relative to the storage root using
+// forward slashes. Notes of a filesystem vault lose their note extension;
+// the other backends already store notes by name.
+func RelativeName(s Storage, n *note.Note) string {
+	fs, ok := s.(*FileSystemStorage)
+	if !ok {
+		return n.Path
+	}
+
+	rel, err := filepath.Rel(fs.rootPath, n.Path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = n.Path
+	}
+	rel = filepath.ToSlash(rel)
+	if fs.settings.HasNoteExtension(rel) {
+		rel = strings.TrimSuffix(rel, filepath.Ext(rel))
+	}
+	return rel
+}
+
+// metadataCreator is implemented by backends that can create a note
+// together with its metadata in one write
+type metadataCreator interface {
+	createNoteWithMetadata(notePath, content string, meta *note.Metadata) (*note.Note, error)
+}
+
+// CopyNote copies a single note from src to dst as name, keeping its
+// metadata. It fails if dst already has a note with that name.
+func CopyNote(src, dst Storage, n *note.Note, name string) error {
+	if !n.ContentLoaded {
+		if err := src.LoadContent(n); err != nil {
+			return fmt.Errorf("failed to read note %s: %w", name, err)
+		}
+	}
+
+	if creator, ok := dst.(metadataCreator); ok {
+		if _, err := creator.createNoteWithMetadata(name, n.Content, n.Metadata); err != nil {
+			return fmt.Errorf("failed to copy note %s: %w", name, err)
+		}
+		return nil
+	}
+
+	created, err := dst.CreateNote(name, n.Content)
+	if err != nil {
+		return fmt.Errorf("failed to copy note %s: %w", name, err)
+	}
+
+	created.Metadata = n.Metadata
+	if err := dst.SaveNote(created); err != nil {
+		return fmt.Errorf("failed to copy metadata for %s: %w", name, err)
+	}
+	return nil
+}
+
+// Copy copies every note from src to dst, keeping names and metadata.
+// It returns the number of copied notes and the notes it skipped, either
+// because they failed to load or because dst already has a note with
+// their name.
+func Copy(ctx context.Context, src, dst Storage) (int, []LoadProblem, error) {
+	notes, skipped, err := src.GetAllNotes(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read source notes: %w", err)
+	}
+
+	copied := 0
+	for _, n := range notes {
+		if err := ctx.Err(); err != nil {
+			return copied, skipped, err
+		}
+
+		name := RelativeName(src, n)
+		_, err := dst.GetNote(name)
+		switch {
+		case err == nil:
+			log.Warn("skipping note whose name is taken", "path", n.Path, "name", name)
+			skipped = append(skipped, LoadProblem{
+				Path: n.Path,
+				File: n.Path,
+				Kind: ProblemNameTaken,
+				Err:  fmt.Errorf("%w: %s", ErrAlreadyExists, name),
+			})
+			continue
+		case !errors.Is(err, ErrNotFound):
+			return copied, skipped, fmt.Errorf("failed to look up note %s: %w", name, err)
+		}
+
+		if err := CopyNote(src, dst, n, name); err != nil {
+			return copied, skipped, err
+		}
+		copied++
+	}
+
+	return copied, skipped, nil
+}
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newTestSQLite opens an empty SQLite vault in a temp directory
+func newTestSQLite(t *testing.T) *SQLiteStorage {
+	t.Helper()
+	s := NewSQLiteStorage(filepath.Join(t.TempDir(), "notes.db"))
+	if err := s.Initialize(); err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// copiedNote is what a copy must keep of a note
+type copiedNote struct {
+	content string
+	title   string
+	tags    []string
+}
+
+// storedNotes loads every note of a storage by its relative name
+func storedNotes(t *testing.T, s Storage) map[string]copiedNote {
+	t.Helper()
+	notes, problems, err := s.GetAllNotes(context.Background())
+	if err != nil || len(problems) > 0 {
+		t.Fatalf("failed to load notes: %v %v", err, problems)
+	}
+
+	stored := make(map[string]copiedNote)
+	for _, n := range notes {
+		if err := s.LoadContent(n); err != nil {
+			t.Fatalf("failed to load %s: %v", n.Path, err)
+		}
+		stored[RelativeName(s, n)] = copiedNote{
+			content: n.Content,
+			title:   n.Metadata.Title,
+			tags:    n.Metadata.Tags,
+		}
+	}
+	return stored
+}
+
+func TestCopyRoundTrip(t *testing.T) {
+	src := newTestVault(t)
+	want := storedNotes(t, src)
+	for _, name := range []string{"todo", "work/plan", "v1.2"} {
+		if _, ok := want[name]; !ok {
+			t.Fatalf("test vault has no note %q: %v", name, want)
+		}
+	}
+
+	sqlite := newTestSQLite(t)
+	memory := NewMemoryStorage("")
+	back := NewFileSystemStorage(t.TempDir())
+	if err := back.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name     string
+		src, dst Storage
+	}{
+		{"filesystem to sqlite", src, sqlite},
+		{"sqlite to memory", sqlite, memory},
+		{"memory to filesystem", memory, back},
+	}
+	for _, step := range steps {
+		copied, skipped, err := Copy(context.Background(), step.src, step.dst)
+		if err != nil || len(skipped) > 0 || copied != len(want) {
+			t.Fatalf("%s: Copy = %d, %v, %v", step.name, copied, skipped, err)
+		}
+
+		got := storedNotes(t, step.dst)
+		for name, w := range want {
+			g, ok := got[name]
+			switch {
+			case !ok:
+				t.Errorf("%s: %s missing, got %v", step.name, name, got)
+			case g.content != w.content || g.title != w.title || !slices.Equal(g.tags, w.tags):
+				t.Errorf("%s: %s = %+v, want %+v", step.name, name, g, w)
+			}
+		}
+	}
+
+	// Copying creates each note and then saves its metadata, which must
+	// not leave a revision of the empty note behind
+	for name := range want {
+		revisions, err := sqlite.Revisions(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(revisions) > 0 {
+			t.Errorf("%s has %d revisions after copying, want none", name, len(revisions))
+		}
+	}
+}
+
+func TestCopySkipped(t *testing.T) {
+	src := NewFileSystemStorage(t.TempDir())
+	files := map[string]string{
+		"todo.md":     "todo in markdown",
+		"todo.txt":    "todo in text",
+		"taken.note":  "taken in the source",
+		"broken.note": "broken",
+		"broken.meta": "{not json",
+		"fine.note":   "fine",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(src.GetRootPath(), name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := src.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := NewMemoryStorage("")
+	if _, err := dst.CreateNote("Taken", "taken in the target"); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, skipped, err := Copy(context.Background(), src, dst)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if copied != 2 {
+		t.Errorf("copied %d notes, want 2 (fine and one todo)", copied)
+	}
+
+	kinds := make(map[string]ProblemKind)
+	for _, p := range skipped {
+		kinds[filepath.Base(p.Path)] = p.Kind
+	}
+	if kinds["taken.note"] != ProblemNameTaken || kinds["broken.note"] != ProblemInvalidMetadata || len(kinds) != 3 {
+		t.Errorf("skipped = %v, want taken.note, broken.note and one todo", kinds)
+	}
+	if kinds["todo.md"] != ProblemNameTaken && kinds["todo.txt"] != ProblemNameTaken {
+		t.Errorf("skipped = %v, want one of the todo notes", kinds)
+	}
+
+	taken, err := dst.GetNote("taken")
+	if err != nil || taken.Content != "taken in the target" {
+		t.Errorf("existing note changed: %v, %v", taken, err)
+	}
+}
+
+func TestRelativeName(t *testing.T) {
+	fs := NewFileSystemStorage(t.TempDir())
+	if err := fs.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	sqlite := newTestSQLite(t)
+	memory := NewMemoryStorage("")
+
+	tests := []struct {
+		name string
+		s    Storage
+		path string
+		want string
+	}{
+		{"filesystem note", fs, "work/todo.note", "work/todo"},
+		{"filesystem markdown", fs, "todo.md", "todo"},
+		{"filesystem dotted name", fs, "v1.2.md", "v1.2"},
+		{"sqlite dotted name", sqlite, "v1.2", "v1.2"},
+		{"sqlite folder", sqlite, "work/v1.2", "work/v1.2"},
+		{"memory dotted name", memory, "release.notes", "release.notes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := tt.s.CreateNote(tt.path, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := RelativeName(tt.s, n); got != tt.want {
+				t.Errorf("RelativeName(%s) = %q, want %q", n.Path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoteNameValidation(t *testing.T) {
+	backends := map[string]func(t *testing.T) Storage{
+		"filesystem": func(t *testing.T) Storage {
+			fs := NewFileSystemStorage(t.TempDir())
+			if err := fs.Initialize(); err != nil {
+				t.Fatal(err)
+			}
+			return fs
+		},
+		"sqlite": func(t *testing.T) Storage { return newTestSQLite(t) },
+		"memory": func(t *testing.T) Storage { return NewMemoryStorage("") },
+	}
+
+	changes := []struct {
+		name   string
+		change func(s Storage, n string) error
+	}{
+		{"create outside the vault", func(s Storage, n string) error {
+			_, err := s.CreateNote("../escape", "")
+			return err
+		}},
+		{"create hidden", func(s Storage, n string) error {
+			_, err := s.CreateNote("work/.hidden", "")
+			return err
+		}},
+		{"rename into a folder", func(s Storage, n string) error {
+			_, err := s.RenameNote(n, "other/name")
+			return err
+		}},
+		{"rename to a reserved name", func(s Storage, n string) error {
+			_, err := s.RenameNote(n, "CON")
+			return err
+		}},
+		{"move outside the vault", func(s Storage, n string) error {
+			_, err := s.MoveNote(n, "..")
+			return err
+		}},
+		{"move into a hidden folder", func(s Storage, n string) error {
+			_, err := s.MoveNote(n, "work/.git")
+			return err
+		}},
+	}
+
+	for backend, open := range backends {
+		for _, tt := range changes {
+			t.Run(backend+"/"+tt.name, func(t *testing.T) {
+				s := open(t)
+				n, err := s.CreateNote("todo", "")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var nameErr *NameError
+				if err := tt.change(s, n.Path); !errors.As(err, &nameErr) {
+					t.Errorf("got %v, want a NameError", err)
+				}
+
+				notes, _, err := s.GetAllNotes(context.Background())
+				if err != nil || len(notes) != 1 || RelativeName(s, notes[0]) != "todo" {
+					t.Errorf("notes changed by a rejected name: %v", notes)
+				}
+			})
+		}
+	}
+}
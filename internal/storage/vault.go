@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"notes-app/internal/note"
+)
+
+// VaultSettingsFile is the name of the per-vault settings file in the vault root
+const VaultSettingsFile = ".vault.json"
+
+// VaultSettings holds settings stored inside a vault
+type VaultSettings struct {
+	MetadataFormat   note.MetadataFormat `json:"metadata_format"`
+	Extensions       []string            `json:"extensions,omitempty"`
+	DefaultExtension string              `json:"default_extension,omitempty"`
+}
+
+// DefaultExtensions are the note file extensions recognized by default
+var DefaultExtensions = []string{note.DefaultExtension, ".md", ".markdown", ".txt"}
+
+// DefaultVaultSettings returns the settings used for vaults without a settings file
+func DefaultVaultSettings() *VaultSettings {
+	return &VaultSettings{
+		MetadataFormat:   note.FormatSidecar,
+		Extensions:       append([]string{}, DefaultExtensions...),
+		DefaultExtension: note.DefaultExtension,
+	}
+}
+
+// LoadVaultSettings reads the settings file from the vault root
+func LoadVaultSettings(rootPath string) (*VaultSettings, error) {
+	settings := DefaultVaultSettings()
+
+	data, err := os.ReadFile(filepath.Join(rootPath, VaultSettingsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return settings, nil
+		}
+		return nil, fmt.Errorf("failed to read vault settings: %w", err)
+	}
+
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("failed to parse vault settings: %w", err)
+	}
+
+	format, err := note.ParseMetadataFormat(string(settings.MetadataFormat))
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault settings: %w", err)
+	}
+	settings.MetadataFormat = format
+
+	if err := settings.normalizeExtensions(); err != nil {
+		return nil, fmt.Errorf("invalid vault settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// normalizeExtensions lowercases extensions, adds missing dots and
+// makes sure the default extension is recognized
+func (s *VaultSettings) normalizeExtensions() error {
+	if len(s.Extensions) == 0 {
+		s.Extensions = append([]string{}, DefaultExtensions...)
+	}
+	if s.DefaultExtension == "" {
+		s.DefaultExtension = s.Extensions[0]
+	}
+
+	var exts []string
+	for _, ext := range s.Extensions {
+		ext = normalizeExtension(ext)
+		if ext == "." || ext == note.MetaExtension {
+			return fmt.Errorf("'%s' cannot be used as a note extension", ext)
+		}
+		exts = append(exts, ext)
+	}
+	s.Extensions = exts
+
+	s.DefaultExtension = normalizeExtension(s.DefaultExtension)
+	if !s.HasNoteExtension("x" + s.DefaultExtension) {
+		return fmt.Errorf("default extension '%s' is not in the list of extensions", s.DefaultExtension)
+	}
+
+	return nil
+}
+
+// HasNoteExtension reports whether the path ends in a recognized note extension
+func (s *VaultSettings) HasNoteExtension(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range s.Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeExtension lowercases an extension and ensures it starts with a dot
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// Save writes the settings file to the vault root
+func (s *VaultSettings) Save(rootPath string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault settings: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(rootPath, VaultSettingsFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write vault settings: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,257 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"notes-app/internal/state"
+)
+
+// draftInterval is how often unsaved editor text is written as drafts
+const draftInterval = 5 * time.Second
+
+// draftTickMsg asks for the unsaved editor text to be written as drafts
+type draftTickMsg struct{}
+
+// draftTick schedules the next autosave of drafts
+func draftTick() tea.Cmd {
+	return tea.Tick(draftInterval, func(time.Time) tea.Msg {
+		return draftTickMsg{}
+	})
+}
+
+// currentDrafts returns the unsaved text of the open tabs and of a note
+// being written
+func (m Model) currentDrafts() []state.Draft {
+	var drafts []state.Draft
+	for _, t := range m.tabs {
+		if t.dirty() {
+			drafts = append(drafts, m.newDraft(t.path, t.name, t.editor.Value()))
+		}
+	}
+	writing := m.state == "create" || m.state == "confirm_discard"
+	if writing && m.newNoteName != "" && m.textarea.Value() != "" {
+		drafts = append(drafts, m.newDraft("", m.newNoteName, m.textarea.Value()))
+	}
+	return drafts
+}
+
+// newDraft returns a draft of this session for a note of the current vault
+func (m Model) newDraft(path, name, content string) state.Draft {
+	return state.Draft{
+		Vault:   m.config.CurrentVault().Name,
+		Path:    path,
+		Name:    name,
+		Content: content,
+		Session: m.draftSession,
+	}
+}
+
+// saveDrafts writes the unsaved text that changed since the last autosave
+// and removes the drafts of text that was saved or given up since. Only
+// drafts of this session are touched; leftover drafts have other ids.
+func (m *Model) saveDrafts() {
+	current := make(map[string]bool)
+	for _, d := range m.currentDrafts() {
+		id := d.ID()
+		current[id] = true
+		if content, ok := m.drafts[id]; ok && content == d.Content {
+			continue
+		}
+		d.Saved = time.Now()
+		if err := state.SaveDraft(d); err != nil {
+			log.Warn("failed to save draft", "note", d.Name, "err", err)
+			continue
+		}
+		m.drafts[id] = d.Content
+	}
+
+	for id := range m.drafts {
+		if current[id] {
+			continue
+		}
+		if err := state.DeleteDraft(id); err != nil {
+			log.Warn("failed to delete draft", "err", err)
+			continue
+		}
+		delete(m.drafts, id)
+	}
+}
+
+// quit removes the drafts of this session, whose text was saved or given
+// up, and closes the vaults
+func (m *Model) quit() tea.Cmd {
+	m.tabs = nil
+	m.newNoteName = ""
+	m.saveDrafts()
+	m.closeVaults()
+	return tea.Quit
+}
+
+// finishNewNote leaves the note being written for the list, dropping its text and draft
+func (m *Model) finishNewNote() {
+	m.state = "list"
+	m.textarea.Reset()
+	m.newNoteName = ""
+	m.saveDrafts()
+}
+
+// updateConfirmDiscard handles keys while dropping the text of a new note is confirmed
+func (m Model) updateConfirmDiscard(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case m.matches(msg, actionSubmit):
+		m.finishNewNote()
+	case m.matches(msg, actionBack):
+		m.state = "create"
+	}
+	return m, nil
+}
+
+// openDrafts shows the drafts left over from an earlier session
+func (m *Model) openDrafts() {
+	m.state = "drafts"
+	m.draftCursor = 0
+}
+
+// updateDrafts handles keys on the drafts screen
+func (m Model) updateDrafts(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case m.matches(msg, actionBack):
+		m.state = "list"
+	case m.matches(msg, actionUp):
+		if m.draftCursor > 0 {
+			m.draftCursor--
+		}
+	case m.matches(msg, actionDown):
+		if m.draftCursor < len(m.leftoverDrafts)-1 {
+			m.draftCursor++
+		}
+	case m.matches(msg, actionRecover):
+		if len(m.leftoverDrafts) > 0 {
+			m.recoverDraft(m.draftCursor)
+		}
+	case m.matches(msg, actionDiscard):
+		if len(m.leftoverDrafts) > 0 {
+			d := m.leftoverDrafts[m.draftCursor]
+			if err := state.DeleteDraft(d.ID()); err != nil {
+				m.fail(err)
+				return m, nil
+			}
+			m.info("Discarded the draft of '%s'", d.Name)
+			m.dropLeftoverDraft(m.draftCursor)
+			if len(m.leftoverDrafts) == 0 {
+				m.state = "list"
+			}
+		}
+	}
+	return m, nil
+}
+
+// dropLeftoverDraft removes a draft from the drafts screen
+func (m *Model) dropLeftoverDraft(i int) {
+	drafts := append([]state.Draft{}, m.leftoverDrafts[:i]...)
+	m.leftoverDrafts = append(drafts, m.leftoverDrafts[i+1:]...)
+	m.draftCursor = max(0, min(m.draftCursor, len(m.leftoverDrafts)-1))
+}
+
+// recoverDraft puts the text of a draft back into the editor: in the tab
+// of its note, or as a new note if the note was never created or is gone
+func (m *Model) recoverDraft(i int) {
+	d := m.leftoverDrafts[i]
+	if d.Vault != m.config.CurrentVault().Name {
+		if err := m.switchVault(d.Vault); err != nil {
+			m.fail(err)
+			return
+		}
+	}
+
+	if d.Path != "" && m.selectTabNote(d.Path) {
+		m.openTab("edit")
+		if m.state != "edit" {
+			return
+		}
+		m.textarea.SetValue(d.Content)
+		t := m.tabs[m.activeTab]
+		m.recovered(i, m.newDraft(t.path, t.name, d.Content))
+		m.info("Recovered the draft of '%s', save it with '%s'", d.Name, m.describe(actionSave))
+		return
+	}
+
+	m.recovered(i, m.newDraft("", d.Name, d.Content))
+	m.state = "create"
+	m.newNoteName = d.Name
+	m.textarea = m.newEditor()
+	m.textarea.SetValue(d.Content)
+	m.textarea.Focus()
+	m.editorScroll = 0
+	m.info("Recovered the draft of '%s' as a new note, save it with '%s'", d.Name, m.describe(actionSave))
+}
+
+// recovered hands a leftover draft over to this session: its text is
+// written as a draft of this session first, and only then is the leftover
+// removed. If that write fails the leftover stays for a later run.
+func (m *Model) recovered(i int, d state.Draft) {
+	leftover := m.leftoverDrafts[i]
+	m.dropLeftoverDraft(i)
+
+	d.Saved = time.Now()
+	if err := state.SaveDraft(d); err != nil {
+		log.Warn("failed to save recovered draft", "note", d.Name, "err", err)
+		return
+	}
+	m.drafts[d.ID()] = d.Content
+	if err := state.DeleteDraft(leftover.ID()); err != nil {
+		log.Warn("failed to delete recovered draft", "note", leftover.Name, "err", err)
+	}
+}
+
+// renderDrafts lists the drafts left over from an earlier session
+func (m Model) renderDrafts() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Drafts") + "\n\n")
+
+	if len(m.leftoverDrafts) == 0 {
+		s.WriteString("There are no drafts to recover.\n")
+		return s.String()
+	}
+	s.WriteString("These edits were not saved when the app last closed:\n\n")
+
+	var list strings.Builder
+	for i, d := range m.leftoverDrafts {
+		cursor := " "
+		if i == m.draftCursor {
+			cursor = ">"
+		}
+
+		words := len(strings.Fields(d.Content))
+		line := fmt.Sprintf("%s %s", cursor, d.Name)
+		if d.Path == "" {
+			line += " (new note)"
+		}
+		line += " " + helpStyle.Render(fmt.Sprintf("%s · %d %s · %s", d.Vault, words, plural(words, "word", "words"),
+			d.Saved.Format(m.config.DateTimeFormat)))
+		if m.width > 0 {
+			line = ansi.Truncate(line, m.width-listItemChromeWidth, "…")
+		}
+
+		if i == m.draftCursor {
+			list.WriteString(selectedNoteStyle.Render(line))
+		} else {
+			list.WriteString(noteStyle.Render(line))
+		}
+		list.WriteString("\n")
+	}
+	s.WriteString(listStyle.Render(strings.TrimSuffix(list.String(), "\n")))
+	return s.String()
+}
+
+// renderConfirmDiscard renders the screen confirming dropping the text of a new note
+func (m Model) renderConfirmDiscard() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Discard Note") + "\n\n")
+	s.WriteString(fmt.Sprintf("'%s' has not been saved. Discard what was written?\n", m.newNoteName))
+	return s.String()
+}
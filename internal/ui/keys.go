@@ -0,0 +1,508 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"notes-app/internal/config"
+)
+
+// Actions that can be bound to keys in the configuration file
+const (
+	actionQuit           = "quit"
+	actionHelp           = "help"
+	actionUp             = "up"
+	actionDown           = "down"
+	actionPageUp         = "page_up"
+	actionPageDown       = "page_down"
+	actionTop            = "top"
+	actionBottom         = "bottom"
+	actionOpen           = "open"
+	actionSubmit         = "submit"
+	actionRename         = "rename"
+	actionNew            = "new"
+	actionEdit           = "edit"
+	actionDelete         = "delete"
+	actionTags           = "tags"
+	actionPreview        = "preview"
+	actionShrinkList     = "shrink_list"
+	actionGrowList       = "grow_list"
+	actionSort           = "sort"
+	actionReverseSort    = "reverse_sort"
+	actionExternalEditor = "external_editor"
+	actionSave           = "save"
+	actionBack           = "back"
+	actionConfirm        = "confirm"
+	actionAddTags        = "add_tags"
+	actionDeleteTags     = "delete_tags"
+	actionProblems       = "problems"
+	actionRepair         = "repair"
+	actionQuarantine     = "quarantine"
+	actionVaults         = "vaults"
+	actionTheme          = "theme"
+	actionSearch         = "search"
+	actionClearFilter    = "clear_filter"
+	actionPalette        = "palette"
+	actionToggleSelect   = "toggle_select"
+	actionSelectRange    = "select_range"
+	actionSelectAll      = "select_all"
+	actionSelectNone     = "select_none"
+	actionInvertSelect   = "invert_selection"
+	actionMove           = "move"
+	actionExport         = "export"
+	actionMerge          = "merge"
+	actionNextTab        = "next_tab"
+	actionPrevTab        = "prev_tab"
+	actionCloseTab       = "close_tab"
+	actionDrafts         = "drafts"
+	actionRecover        = "recover"
+	actionDiscard        = "discard"
+
+	actionDismiss            = "dismiss"
+	actionNotifications      = "notifications"
+	actionClearNotifications = "clear_notifications"
+)
+
+// globalActions work on every screen
+var globalActions = []string{actionQuit, actionDismiss}
+
+// actionDef is the default binding and help text of an action
+type actionDef struct {
+	keys []string
+	help string
+}
+
+// defaultKeys are the built-in bindings for every action
+var defaultKeys = map[string]actionDef{
+	actionQuit:           {[]string{"ctrl+c", "ctrl+q"}, "quit"},
+	actionHelp:           {[]string{"ctrl+h", "?"}, "help"},
+	actionUp:             {[]string{"up", "k"}, "up"},
+	actionDown:           {[]string{"down", "j"}, "down"},
+	actionPageUp:         {[]string{"pgup", "ctrl+b"}, "page up"},
+	actionPageDown:       {[]string{"pgdown", "ctrl+f"}, "page down"},
+	actionTop:            {[]string{"home", "g"}, "first note"},
+	actionBottom:         {[]string{"end", "G"}, "last note"},
+	actionOpen:           {[]string{"enter"}, "open"},
+	actionSubmit:         {[]string{"enter"}, "confirm"},
+	actionRename:         {[]string{"r"}, "rename"},
+	actionNew:            {[]string{"ctrl+n", "n"}, "new note"},
+	actionEdit:           {[]string{"ctrl+e", "e"}, "edit"},
+	actionDelete:         {[]string{"ctrl+d", "d"}, "delete"},
+	actionTags:           {[]string{"ctrl+t", "t"}, "tags"},
+	actionPreview:        {[]string{" "}, "preview"},
+	actionShrinkList:     {[]string{"<"}, "narrow list"},
+	actionGrowList:       {[]string{">"}, "widen list"},
+	actionSort:           {[]string{"s"}, "sort field"},
+	actionReverseSort:    {[]string{"S"}, "sort direction"},
+	actionExternalEditor: {[]string{"o"}, "external editor"},
+	actionSave:           {[]string{"ctrl+s"}, "save"},
+	actionBack:           {[]string{"esc"}, "back"},
+	actionConfirm:        {[]string{"y"}, "yes, delete"},
+	actionAddTags:        {[]string{"ctrl+a"}, "add tags"},
+	actionDeleteTags:     {[]string{"ctrl+d"}, "delete tags"},
+	actionProblems:       {[]string{"!"}, "load problems"},
+	actionRepair:         {[]string{"r"}, "repair metadata"},
+	actionQuarantine:     {[]string{"x"}, "quarantine"},
+	actionVaults:         {[]string{"V"}, "switch vault"},
+	actionTheme:          {[]string{"T"}, "switch theme"},
+	actionSearch:         {[]string{"/"}, "search vaults"},
+	actionClearFilter:    {[]string{"esc"}, "clear tag filter"},
+	actionPalette:        {[]string{"ctrl+p"}, "commands"},
+	actionToggleSelect:   {[]string{"x"}, "select"},
+	actionSelectRange:    {[]string{"v"}, "select range"},
+	actionSelectAll:      {[]string{"A"}, "select all"},
+	actionSelectNone:     {[]string{"U"}, "select none"},
+	actionInvertSelect:   {[]string{"I"}, "invert selection"},
+	actionMove:           {[]string{"m"}, "move to folder"},
+	actionExport:         {[]string{"E"}, "export"},
+	actionMerge:          {[]string{"M"}, "merge"},
+	actionNextTab:        {[]string{"tab", "ctrl+pgdown"}, "next tab"},
+	actionPrevTab:        {[]string{"shift+tab", "ctrl+pgup"}, "previous tab"},
+	actionCloseTab:       {[]string{"ctrl+w"}, "close tab"},
+	actionDrafts:         {[]string{"D"}, "drafts"},
+	actionRecover:        {[]string{"enter"}, "recover"},
+	actionDiscard:        {[]string{"d"}, "discard"},
+
+	actionDismiss:            {[]string{"ctrl+x"}, "dismiss message"},
+	actionNotifications:      {[]string{"N"}, "notifications"},
+	actionClearNotifications: {[]string{"c"}, "clear all"},
+}
+
+// keyScope lists the actions available on one screen
+type keyScope struct {
+	title string
+	// actions are listed in the order they appear in the full help
+	actions []string
+	// short are the actions shown in the help bar
+	short []string
+	// keys replace the default keys of actions on this screen
+	keys map[string][]string
+}
+
+// keyScopes maps each screen to its actions. The global actions work on every screen.
+var keyScopes = map[string]keyScope{
+	"list": {
+		title: "Note list",
+		actions: []string{actionUp, actionDown, actionPageUp, actionPageDown, actionTop, actionBottom,
+			actionOpen, actionNew, actionEdit, actionRename, actionDelete, actionTags,
+			actionPreview, actionShrinkList, actionGrowList, actionSort, actionReverseSort,
+			actionExternalEditor, actionSearch, actionClearFilter, actionVaults, actionTheme,
+			actionToggleSelect, actionSelectRange, actionSelectAll, actionSelectNone, actionInvertSelect,
+			actionMove, actionExport, actionMerge, actionNextTab, actionDrafts, actionProblems, actionNotifications, actionDismiss,
+			actionPalette, actionHelp, actionQuit},
+		short: []string{actionOpen, actionNew, actionEdit, actionDelete, actionSearch, actionPalette, actionHelp, actionQuit},
+	},
+	"view": {
+		title: "Viewing a note",
+		actions: []string{actionUp, actionDown, actionPageUp, actionPageDown,
+			actionEdit, actionTags, actionDelete, actionExternalEditor,
+			actionNextTab, actionPrevTab, actionCloseTab, actionPalette, actionHelp, actionBack},
+		short: []string{actionEdit, actionTags, actionNextTab, actionCloseTab, actionPalette, actionHelp, actionBack},
+	},
+	"help": {
+		title:   "Help",
+		actions: []string{actionBack},
+		short:   []string{actionBack},
+	},
+	"create_name": {
+		title:   "Naming a new note",
+		actions: []string{actionSubmit, actionBack},
+		short:   []string{actionSubmit, actionBack},
+	},
+	"rename": {
+		title:   "Renaming a note",
+		actions: []string{actionSubmit, actionBack},
+		short:   []string{actionSubmit, actionBack},
+	},
+	"create": {
+		title:   "Writing a new note",
+		actions: []string{actionSave, actionBack},
+		short:   []string{actionSave, actionBack},
+	},
+	"edit": {
+		title:   "Editing a note",
+		actions: []string{actionSave, actionNextTab, actionPrevTab, actionCloseTab, actionBack},
+		short:   []string{actionSave, actionNextTab, actionCloseTab, actionBack},
+		// Tab is left to the text being written
+		keys: map[string][]string{actionNextTab: {"ctrl+pgdown"}, actionPrevTab: {"ctrl+pgup"}},
+	},
+	"confirm_delete": {
+		title:   "Deleting notes",
+		actions: []string{actionConfirm, actionBack},
+		short:   []string{actionConfirm, actionBack},
+		keys:    map[string][]string{actionBack: {"esc", "n"}},
+	},
+	"confirm_merge": {
+		title:   "Merging notes",
+		actions: []string{actionSubmit, actionBack},
+		short:   []string{actionSubmit, actionBack},
+		keys:    map[string][]string{actionSubmit: {"y", "enter"}, actionBack: {"esc", "n"}},
+	},
+	"confirm_close": {
+		title:   "Closing a tab",
+		actions: []string{actionSubmit, actionBack},
+		short:   []string{actionSubmit, actionBack},
+		keys:    map[string][]string{actionSubmit: {"y", "enter"}, actionBack: {"esc", "n"}},
+	},
+	"confirm_quit": {
+		title:   "Quitting",
+		actions: []string{actionSubmit, actionBack},
+		short:   []string{actionSubmit, actionBack},
+		keys:    map[string][]string{actionSubmit: {"y", "enter"}, actionBack: {"esc", "n"}},
+	},
+	"confirm_discard": {
+		title:   "Discarding a new note",
+		actions: []string{actionSubmit, actionBack},
+		short:   []string{actionSubmit, actionBack},
+		keys:    map[string][]string{actionSubmit: {"y", "enter"}, actionBack: {"esc", "n"}},
+	},
+	"drafts": {
+		title:   "Drafts",
+		actions: []string{actionUp, actionDown, actionRecover, actionDiscard, actionPalette, actionBack},
+		short:   []string{actionRecover, actionDiscard, actionBack},
+	},
+	"move": {
+		title:   "Moving notes",
+		actions: []string{actionSubmit, actionBack},
+		short:   []string{actionSubmit, actionBack},
+	},
+	"export": {
+		title:   "Exporting notes",
+		actions: []string{actionSubmit, actionBack},
+		short:   []string{actionSubmit, actionBack},
+	},
+	"tags": {
+		title:   "Tags",
+		actions: []string{actionAddTags, actionDeleteTags, actionPalette, actionBack},
+		short:   []string{actionAddTags, actionDeleteTags, actionBack},
+	},
+	"tag_input": {
+		title:   "Entering tags",
+		actions: []string{actionSubmit, actionBack},
+		short:   []string{actionSubmit, actionBack},
+	},
+	"problems": {
+		title:   "Load problems",
+		actions: []string{actionUp, actionDown, actionExternalEditor, actionRepair, actionQuarantine, actionPalette, actionBack},
+		short:   []string{actionExternalEditor, actionRepair, actionQuarantine, actionBack},
+		keys:    map[string][]string{actionExternalEditor: {"o", "enter"}},
+	},
+	"vaults": {
+		title:   "Vaults",
+		actions: []string{actionUp, actionDown, actionOpen, actionPalette, actionBack},
+		short:   []string{actionOpen, actionBack},
+	},
+	"search": {
+		title:   "Search",
+		actions: []string{actionUp, actionDown, actionSubmit, actionBack},
+		short:   []string{actionUp, actionDown, actionSubmit, actionBack},
+		// Letters are typed into the query
+		keys: map[string][]string{actionUp: {"up"}, actionDown: {"down"}},
+	},
+	"notifications": {
+		title:   "Notifications",
+		actions: []string{actionUp, actionDown, actionClearNotifications, actionPalette, actionBack},
+		short:   []string{actionClearNotifications, actionBack},
+	},
+	"palette": {
+		title:   "Commands",
+		actions: []string{actionUp, actionDown, actionSubmit, actionBack},
+		short:   []string{actionSubmit, actionBack},
+		keys:    map[string][]string{actionUp: {"up", "ctrl+k"}, actionDown: {"down", "ctrl+j"}},
+	},
+}
+
+// scopeKeys holds the bindings of one screen and implements help.KeyMap
+type scopeKeys struct {
+	title    string
+	actions  []string
+	short    []string
+	bindings map[string]key.Binding
+}
+
+// ShortHelp returns the bindings shown in the help bar
+func (k scopeKeys) ShortHelp() []key.Binding {
+	bindings := make([]key.Binding, 0, len(k.short))
+	for _, action := range k.short {
+		bindings = append(bindings, k.bindings[action])
+	}
+	return bindings
+}
+
+// FullHelp returns every binding of the screen in columns
+func (k scopeKeys) FullHelp() [][]key.Binding {
+	const columnSize = 8
+
+	var columns [][]key.Binding
+	for i, action := range k.actions {
+		if i%columnSize == 0 {
+			columns = append(columns, nil)
+		}
+		columns[len(columns)-1] = append(columns[len(columns)-1], k.bindings[action])
+	}
+	return columns
+}
+
+// keyMap holds the bindings of every screen
+type keyMap map[string]scopeKeys
+
+// newKeyMap builds the key map from the defaults and configured overrides.
+// An override named after an action applies on every screen; one named
+// "screen.action" applies to that screen only and wins.
+func newKeyMap(overrides map[string][]string) keyMap {
+	keys := make(keyMap, len(keyScopes))
+	for name, scope := range keyScopes {
+		sk := scopeKeys{
+			title:    scope.title,
+			actions:  scope.actions,
+			short:    scope.short,
+			bindings: make(map[string]key.Binding, len(scope.actions)+len(globalActions)),
+		}
+
+		for _, action := range append(append([]string{}, globalActions...), scope.actions...) {
+			bound := defaultKeys[action].keys
+			if k, ok := scope.keys[action]; ok {
+				bound = k
+			}
+			if k, ok := overrides[action]; ok {
+				bound = normalizeKeys(k)
+			}
+			if k, ok := overrides[name+"."+action]; ok {
+				bound = normalizeKeys(k)
+			}
+
+			sk.bindings[action] = key.NewBinding(
+				key.WithKeys(bound...),
+				key.WithHelp(keyNames(bound), defaultKeys[action].help),
+			)
+		}
+		keys[name] = sk
+	}
+	return keys
+}
+
+// matches reports whether the key message triggers the action on a screen
+func (k keyMap) matches(scope string, msg tea.KeyMsg, action string) bool {
+	binding, ok := k[scope].bindings[action]
+	return ok && key.Matches(msg, binding)
+}
+
+// describe returns the keys for an action on a screen in a human readable form
+func (k keyMap) describe(scope, action string) string {
+	return k[scope].bindings[action].Help().Key
+}
+
+// keyScope returns the name of the key scope of the current screen
+func (m Model) keyScope() string {
+	if m.state == "tags" && m.tagEditMode != "" {
+		return "tag_input"
+	}
+	return m.state
+}
+
+// matches reports whether the key message triggers the action on the current screen
+func (m Model) matches(msg tea.KeyMsg, action string) bool {
+	return m.keys.matches(m.keyScope(), msg, action)
+}
+
+// describe returns the keys for an action on the current screen
+func (m Model) describe(action string) string {
+	return m.keys.describe(m.keyScope(), action)
+}
+
+// newHelp returns a help view styled with the current theme
+func (m Model) newHelp() help.Model {
+	h := help.New()
+	h.Width = m.width - mainMarginWidth
+	h.Styles.ShortKey = helpStyle.Bold(true)
+	h.Styles.ShortDesc = helpStyle
+	h.Styles.ShortSeparator = helpStyle
+	h.Styles.Ellipsis = helpStyle
+	h.Styles.FullKey = helpStyle.Bold(true)
+	h.Styles.FullDesc = helpStyle
+	h.Styles.FullSeparator = helpStyle
+	return h
+}
+
+// renderShortHelp renders the help bar for the current screen
+func (m Model) renderShortHelp() string {
+	return m.newHelp().ShortHelpView(m.keys[m.keyScope()].ShortHelp())
+}
+
+// renderHelp renders every binding of the screen help was opened from
+func (m Model) renderHelp() string {
+	var s strings.Builder
+	scope := m.keys[m.helpScope]
+
+	s.WriteString(titleStyle.Render("Help: "+scope.title) + "\n\n")
+	s.WriteString(m.newHelp().FullHelpView(scope.FullHelp()) + "\n\n")
+	s.WriteString(helpStyle.Render(fmt.Sprintf("Keys can be changed in the [keybindings] section of %s", m.config.Path())))
+	return s.String()
+}
+
+// normalizeKeys converts key names from the configuration to tea key strings
+func normalizeKeys(keys []string) []string {
+	normalized := make([]string, 0, len(keys))
+	for _, k := range keys {
+		k = strings.TrimSpace(k)
+		if strings.EqualFold(k, "space") {
+			k = " "
+		}
+		normalized = append(normalized, k)
+	}
+	return normalized
+}
+
+// keyNames returns keys in a human readable form for help texts
+func keyNames(keys []string) string {
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		switch k {
+		case " ":
+			k = "space"
+		case "up":
+			k = "↑"
+		case "down":
+			k = "↓"
+		}
+		names = append(names, k)
+	}
+	return strings.Join(names, "/")
+}
+
+// ValidateKeybindings reports configured actions or screens that do not
+// exist, and keys that would trigger two actions on the same screen
+func ValidateKeybindings(cfg *config.Config) []string {
+	var problems []string
+	for name := range cfg.Keybindings {
+		scope, action, scoped := strings.Cut(name, ".")
+		if !scoped {
+			action, scope = scope, ""
+		}
+
+		if _, ok := defaultKeys[action]; !ok {
+			problems = append(problems, fmt.Sprintf("keybindings.%s: '%s' is not a known action", name, action))
+			continue
+		}
+		if !scoped {
+			continue
+		}
+		s, ok := keyScopes[scope]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("keybindings.%s: '%s' is not a known screen", name, scope))
+			continue
+		}
+		if !contains(globalActions, action) && !contains(s.actions, action) {
+			problems = append(problems, fmt.Sprintf("keybindings.%s: '%s' is not available on the %s screen", name, action, scope))
+		}
+	}
+
+	keys := newKeyMap(cfg.Keybindings)
+	for scope, sk := range keys {
+		problems = append(problems, sk.conflicts(scope, cfg.Keybindings)...)
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// conflicts reports keys bound to two actions on the screen where at least
+// one of them was configured by the user
+func (k scopeKeys) conflicts(scope string, overrides map[string][]string) []string {
+	configured := func(action string) bool {
+		_, global := overrides[action]
+		_, scoped := overrides[scope+"."+action]
+		return global || scoped
+	}
+
+	var problems []string
+	owner := make(map[string]string)
+	for _, action := range append(append([]string{}, globalActions...), k.actions...) {
+		for _, bound := range k.bindings[action].Keys() {
+			other, taken := owner[bound]
+			if !taken {
+				owner[bound] = action
+				continue
+			}
+			if other != action && (configured(action) || configured(other)) {
+				problems = append(problems, fmt.Sprintf("keybindings: '%s' is bound to both %s and %s on the %s screen",
+					keyNames([]string{bound}), other, action, scope))
+			}
+		}
+	}
+	return problems
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
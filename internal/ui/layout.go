@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"notes-app/internal/config"
+	"notes-app/internal/note"
+)
+
+const (
+	// minSplitWidth is the narrowest terminal that shows the preview beside the list
+	minSplitWidth = 100
+	// minSplitRatio and maxSplitRatio bound the share of the width given to the list
+	minSplitRatio = 0.2
+	maxSplitRatio = 0.8
+	// splitRatioStep is how much one key press moves the split
+	splitRatioStep = 0.05
+	// mainMarginWidth is the horizontal margin of mainStyle on both sides
+	mainMarginWidth = 4
+	// mainMarginTop and mainMarginLeft are where mainStyle starts drawing
+	mainMarginTop  = 1
+	mainMarginLeft = 2
+	// paneGap is the space between the list and the preview
+	paneGap = 1
+	// previewContentHeight is the number of content lines in the stacked preview box
+	previewContentHeight = 4
+	// boxChromeWidth covers the border and padding of the list and preview boxes
+	boxChromeWidth = 6
+)
+
+// ValidateSplitRatio reports a split ratio outside the supported range
+func ValidateSplitRatio(cfg *config.Config) []string {
+	if cfg.SplitRatio < minSplitRatio || cfg.SplitRatio > maxSplitRatio {
+		return []string{fmt.Sprintf("split_ratio must be between %.1f and %.1f", minSplitRatio, maxSplitRatio)}
+	}
+	return nil
+}
+
+// splitLayout reports whether the preview is shown beside the list
+func (m Model) splitLayout() bool {
+	return m.showPreview && m.width >= minSplitWidth
+}
+
+// paneWidths returns the outer widths of the list and the preview in split layout
+func (m Model) paneWidths() (list, preview int) {
+	available := m.width - mainMarginWidth - paneGap
+	list = int(float64(available) * m.splitRatio)
+	return list, available - list
+}
+
+// adjustSplit moves the split between list and preview and remembers it
+func (m *Model) adjustSplit(delta float64) {
+	ratio := m.splitRatio + delta
+	ratio = max(minSplitRatio, min(maxSplitRatio, ratio))
+	if ratio == m.splitRatio {
+		return
+	}
+
+	m.splitRatio = ratio
+	m.session.SplitRatio = ratio
+	if err := m.session.Save(); err != nil {
+		m.fail(err)
+	}
+}
+
+// listItemWidth returns how wide a row of the list may be
+func (m Model) listItemWidth() int {
+	if m.splitLayout() {
+		list, _ := m.paneWidths()
+		// The row's own padding sits inside the box
+		return list - boxChromeWidth - 4
+	}
+	return m.width - listItemChromeWidth
+}
+
+// listBoxStyle returns the style of the list box, which fills its pane in split layout
+func (m Model) listBoxStyle() lipgloss.Style {
+	if !m.splitLayout() {
+		return listStyle
+	}
+	// Width and height exclude the border
+	list, _ := m.paneWidths()
+	return listStyle.Width(list - 2).Height(m.visibleRows() + 2)
+}
+
+// joinPanes places the rendered list box and the preview of the selected note side by side
+func (m Model) joinPanes(listBox string) string {
+	_, previewWidth := m.paneWidths()
+
+	height := lipgloss.Height(listBox)
+	preview := m.renderPreview(previewWidth-boxChromeWidth, height-4, true)
+	previewBox := previewStyle.
+		Height(height - 2).
+		Width(previewWidth - 2).
+		Render(preview)
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, listBox, strings.Repeat(" ", paneGap), previewBox)
+}
+
+// renderStackedPreview renders the preview box below the list
+func (m Model) renderStackedPreview() string {
+	width := StandardWidth
+	if m.width > 0 {
+		width = m.width - mainMarginWidth - boxChromeWidth
+	}
+
+	content := m.renderPreview(width, previewContentHeight, false)
+	return previewTitleStyle.Render("Preview") + "\n" + previewStyle.Render(content)
+}
+
+// renderPreview renders the Markdown of the selected note wrapped to width
+// and cut to height lines, optionally starting with its title and tags
+func (m Model) renderPreview(width, height int, withHeader bool) string {
+	if len(m.notes) == 0 || width <= 0 || height <= 0 {
+		return ""
+	}
+
+	n := m.notes[m.cursor]
+	var text string
+	if content, err := m.notesApp.GetContent(n); err != nil {
+		text = lipgloss.NewStyle().Width(width).Render(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+	} else {
+		// One line more than fits, so a cut preview ends with the mark below
+		text = renderMarkdown(content, width, height+1)
+	}
+
+	if withHeader {
+		text = m.previewHeader(n, width) + "\n\n" + text
+	}
+
+	lines := strings.Split(text, "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+		lines[height-1] = helpStyle.Render("…")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// previewHeader renders a note's title and tags for the preview pane
+func (m Model) previewHeader(n *note.Note, width int) string {
+	header := titleStyle.UnsetMarginBottom().Render(ansi.Truncate(n.DisplayTitle(), width-2, "…"))
+	if len(n.Metadata.Tags) > 0 {
+		header += "\n" + tagStyle.Render(ansi.Truncate(fmt.Sprintf("[%s]", strings.Join(n.Metadata.Tags, ", ")), width, "…"))
+	}
+
+	return header
+}
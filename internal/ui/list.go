@@ -0,0 +1,192 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"notes-app/internal/note"
+)
+
+const (
+	// defaultListRows is used until the terminal size is known
+	defaultListRows = 20
+	// listChromeHeight covers the margins, list border and padding, footer, help bar and status bar
+	listChromeHeight = 10
+	// listItemChromeWidth covers the margins, list border and padding around a row
+	listItemChromeWidth = 14
+	// previewHeight is the height of the preview box including its title
+	previewHeight = 11
+)
+
+// visibleRows returns how many notes fit in the list
+func (m Model) visibleRows() int {
+	if m.height <= 0 {
+		return defaultListRows
+	}
+
+	rows := m.height - listChromeHeight
+	if m.showPreview && !m.splitLayout() {
+		rows -= previewHeight
+	}
+	if len(m.notesApp.Problems()) > 0 {
+		rows--
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	return rows
+}
+
+// moveCursor moves the cursor by delta notes, clamped to the list
+func (m *Model) moveCursor(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.notes) {
+		m.cursor = len(m.notes) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	m.ensureCursorVisible()
+}
+
+// ensureCursorVisible scrolls the list so the cursor is inside the visible window
+func (m *Model) ensureCursorVisible() {
+	rows := m.visibleRows()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+	if maxOffset := len(m.notes) - rows; m.offset > maxOffset {
+		m.offset = maxOffset
+	}
+	if m.offset < 0 {
+		m.offset = 0
+	}
+}
+
+// renderList renders the visible part of the note list, the optional preview and the footer
+func (m Model) renderList() string {
+	var s strings.Builder
+
+	// m is a copy, so this only adjusts the window for this frame
+	m.ensureCursorVisible()
+
+	switch {
+	case len(m.notes) == 0 && m.tagFilter != "":
+		s.WriteString(listStyle.Render(fmt.Sprintf("No notes tagged '%s'.", m.tagFilter)))
+	case len(m.notes) == 0:
+		s.WriteString(listStyle.Render(fmt.Sprintf("No notes found. Press '%s' to create one.", m.describe(actionNew))))
+	default:
+		start := m.offset
+		end := start + m.visibleRows()
+		if end > len(m.notes) {
+			end = len(m.notes)
+		}
+
+		var listContent strings.Builder
+		for i := start; i < end; i++ {
+			listContent.WriteString(m.renderListItem(i, m.notes[i]))
+			listContent.WriteString("\n")
+		}
+		listBox := m.listBoxStyle().Render(strings.TrimSuffix(listContent.String(), "\n"))
+
+		switch {
+		case m.splitLayout():
+			s.WriteString(m.joinPanes(listBox))
+		case m.showPreview:
+			s.WriteString(listBox + "\n" + m.renderStackedPreview())
+		default:
+			s.WriteString(listBox)
+		}
+	}
+
+	position := "0/0"
+	if len(m.notes) > 0 {
+		position = fmt.Sprintf("%d/%d", m.cursor+1, len(m.notes))
+	}
+	if len(m.config.VaultNames()) > 1 {
+		position = fmt.Sprintf("%s · %s", m.config.CurrentVault().Name, position)
+	}
+	if m.tagFilter != "" {
+		position = fmt.Sprintf("%s · Tagged '%s' (%s to clear)", position, m.tagFilter, m.describe(actionClearFilter))
+	}
+	if len(m.selected) > 0 {
+		position = fmt.Sprintf("%s · %d selected", position, len(m.selected))
+	}
+	s.WriteString("\n" + helpStyle.Render(fmt.Sprintf("%s · Sorted by %s", position, sortDescription(m.sortOrder))))
+
+	if count := len(m.notesApp.Problems()); count > 0 {
+		s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("⚠ %d %s could not be loaded, press '%s' for details",
+			count, plural(count, "note", "notes"), m.describe(actionProblems))))
+	}
+
+	return s.String()
+}
+
+// listPrefix returns the cursor and selection marks that start a list row
+func (m Model) listPrefix(i int) string {
+	cursor, mark := " ", " "
+	if m.cursor == i {
+		cursor = ">"
+	}
+	if m.isSelected(i) {
+		mark = "✓"
+	}
+	return cursor + mark + " "
+}
+
+// renderListItem renders a single row of the note list
+func (m Model) renderListItem(i int, n *note.Note) string {
+	noteText := m.listPrefix(i) + n.DisplayTitle()
+
+	if len(n.Metadata.Tags) > 0 {
+		noteText += " " + tagStyle.Render(
+			fmt.Sprintf("[%s]", strings.Join(n.Metadata.Tags, ", ")),
+		)
+	}
+
+	if date := m.listDate(n); date != "" {
+		noteText += " " + helpStyle.Render(date)
+	}
+
+	// Keep every note on one line so the visible window stays accurate
+	if m.width > 0 {
+		noteText = ansi.Truncate(noteText, m.listItemWidth(), "…")
+	}
+
+	if m.cursor == i {
+		return selectedNoteStyle.Render(noteText)
+	}
+	return noteStyle.Render(noteText)
+}
+
+// listDate returns the date shown next to a note when sorting by time
+func (m Model) listDate(n *note.Note) string {
+	switch m.sortOrder.Field {
+	case note.SortByModified:
+		return n.ModTime.Format(m.config.DateFormat)
+	case note.SortByCreated:
+		return n.Metadata.Created.Format(m.config.DateFormat)
+	default:
+		return ""
+	}
+}
+
+// sortDescription describes a sort order for the list footer
+func sortDescription(order note.SortOrder) string {
+	if order.Descending {
+		return string(order.Field) + " ↓"
+	}
+	return string(order.Field) + " ↑"
+}
+
+// plural picks the singular or plural form for a count
+func plural(count int, singular, plural string) string {
+	if count == 1 {
+		return singular
+	}
+	return plural
+}
@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// markdownTabWidth is how many spaces a tab in a code block takes
+const markdownTabWidth = 4
+
+var (
+	// markdownHeading matches an ATX heading such as "## Title"
+	markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	// markdownBullet matches an unordered list item, keeping its indent
+	markdownBullet = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	// markdownNumbered matches an ordered list item, keeping its indent and number
+	markdownNumbered = regexp.MustCompile(`^(\s*)(\d{1,9}[.)])\s+(.*)$`)
+	// markdownTask matches the checkbox that starts a task list item
+	markdownTask = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	// markdownRule matches a horizontal rule
+	markdownRule = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	// markdownInline matches code spans, links, bold and italic text
+	markdownInline = regexp.MustCompile("`([^`]+)`" + `|\[([^\]]+)\]\(([^)\s]*)\)|\*\*([^*]+)\*\*|__([^_]+)__|\*([^*\s][^*]*)\*`)
+)
+
+// renderMarkdown renders Markdown for the terminal with the theme styles,
+// wrapped to width. Rendering stops after maxLines lines.
+// It covers headings, lists, quotes, rules, code and inline emphasis;
+// anything else is shown as written.
+func renderMarkdown(src string, width, maxLines int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	var out []string
+	inCode := false
+	for _, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		if len(out) >= maxLines {
+			break
+		}
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			// Code keeps its layout, so long lines are cut instead of wrapped
+			code := strings.ReplaceAll(line, "\t", strings.Repeat(" ", markdownTabWidth))
+			out = append(out, markdownCodeStyle.Render(ansi.Truncate(code, width, "…")))
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			out = append(out, "")
+		case markdownRule.MatchString(line):
+			out = append(out, helpStyle.Render(strings.Repeat("─", width)))
+		case markdownHeading.MatchString(trimmed):
+			text := markdownHeading.FindStringSubmatch(trimmed)[2]
+			out = append(out, wrapMarkdown(renderInline(text, markdownHeadingStyle), width, "", "")...)
+		case strings.HasPrefix(trimmed, ">"):
+			text := strings.TrimSpace(strings.TrimLeft(trimmed, "> "))
+			bar := helpStyle.Render("│ ")
+			out = append(out, wrapMarkdown(renderInline(text, helpStyle), width, bar, bar)...)
+		case markdownBullet.MatchString(line):
+			parts := markdownBullet.FindStringSubmatch(line)
+			marker := "• "
+			text := parts[2]
+			if task := markdownTask.FindStringSubmatch(text); task != nil {
+				marker = "☐ "
+				if task[1] != " " {
+					marker = "☑ "
+				}
+				text = text[len(task[0]):]
+			}
+			out = append(out, wrapListItem(parts[1], marker, text, width)...)
+		case markdownNumbered.MatchString(line):
+			parts := markdownNumbered.FindStringSubmatch(line)
+			out = append(out, wrapListItem(parts[1], parts[2]+" ", parts[3], width)...)
+		default:
+			out = append(out, wrapMarkdown(renderInline(trimmed, lipgloss.NewStyle()), width, "", "")...)
+		}
+	}
+
+	if len(out) > maxLines {
+		out = out[:maxLines]
+	}
+	return strings.Join(out, "\n")
+}
+
+// wrapListItem renders a list item with its marker, indenting the lines it
+// wraps onto to line up with the text after the marker
+func wrapListItem(indent, marker, text string, width int) []string {
+	// Nested items are indented by two columns per level
+	indent = strings.Repeat(" ", min(ansi.StringWidth(strings.ReplaceAll(indent, "\t", "    ")), width/2))
+	first := indent + helpStyle.Render(marker)
+	rest := strings.Repeat(" ", ansi.StringWidth(indent+marker))
+	return wrapMarkdown(renderInline(text, lipgloss.NewStyle()), width, first, rest)
+}
+
+// wrapMarkdown wraps styled text to width, starting the first line with
+// first and every other line with rest
+func wrapMarkdown(text string, width int, first, rest string) []string {
+	limit := max(1, width-max(ansi.StringWidth(first), ansi.StringWidth(rest)))
+	lines := strings.Split(ansi.Wrap(text, limit, ""), "\n")
+	for i := range lines {
+		if i == 0 {
+			lines[i] = first + lines[i]
+		} else {
+			lines[i] = rest + lines[i]
+		}
+	}
+	return lines
+}
+
+// renderInline styles the code spans, links, bold and italic text of a
+// line. Text in between is rendered with base.
+func renderInline(text string, base lipgloss.Style) string {
+	var s strings.Builder
+	last := 0
+	for _, m := range markdownInline.FindAllStringSubmatchIndex(text, -1) {
+		s.WriteString(base.Render(text[last:m[0]]))
+		group := func(i int) string { return text[m[2*i]:m[2*i+1]] }
+		switch {
+		case m[2] >= 0:
+			s.WriteString(markdownCodeStyle.Render(group(1)))
+		case m[4] >= 0:
+			s.WriteString(markdownLinkStyle.Inherit(base).Render(group(2)))
+		case m[8] >= 0:
+			s.WriteString(base.Bold(true).Render(group(4)))
+		case m[10] >= 0:
+			s.WriteString(base.Bold(true).Render(group(5)))
+		case m[12] >= 0:
+			s.WriteString(base.Italic(true).Render(group(6)))
+		}
+		last = m[1]
+	}
+	s.WriteString(base.Render(text[last:]))
+	return s.String()
+}
@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		width    int
+		maxLines int
+		want     string
+	}{
+		{name: "heading", src: "## Plans *soon* ##", width: 20, maxLines: 5, want: "Plans soon"},
+		{name: "inline", src: "buy **milk**, `eggs` and [bread](https://example.com)", width: 40, maxLines: 5, want: "buy milk, eggs and bread"},
+		{name: "words with underscores", src: "snake_case_name", width: 20, maxLines: 5, want: "snake_case_name"},
+		{name: "wrapped paragraph", src: "one two three four", width: 9, maxLines: 5, want: "one two\nthree\nfour"},
+		{name: "list", src: "- one two three\n  - nested\n1. first", width: 10, maxLines: 5, want: "• one two\n  three\n  • nested\n1. first"},
+		{name: "tasks", src: "- [ ] open\n- [x] done", width: 20, maxLines: 5, want: "☐ open\n☑ done"},
+		{name: "quote", src: "> said *this*", width: 20, maxLines: 5, want: "│ said this"},
+		{name: "rule", src: "* * *", width: 5, maxLines: 5, want: "─────"},
+		{name: "code is cut, not wrapped", src: "```\n**not bold** and long\n```", width: 10, maxLines: 5, want: "**not bol…"},
+		{name: "max lines", src: "a\nb\nc", width: 10, maxLines: 2, want: "a\nb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ansi.Strip(renderMarkdown(tt.src, tt.width, tt.maxLines)); got != tt.want {
+				t.Errorf("renderMarkdown(%q) =\n%s\nwant\n%s", tt.src, got, tt.want)
+			}
+		})
+	}
+}
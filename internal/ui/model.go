@@ -1,228 +1,648 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"notes-app/internal/app"
+	"notes-app/internal/config"
+	"notes-app/internal/logger"
 	"notes-app/internal/note"
+	"notes-app/internal/state"
+	"notes-app/internal/storage"
 )
 
+var log = logger.For("ui")
+
 type Model struct {
-	notes       []*note.Note
-	input       textinput.Model
-	textarea    textarea.Model
-	tagInput    textinput.Model
-	notesApp    *app.NotesApp
-	cursor      int
-	selected    map[int]struct{}
-	state       string // "list", "help", "create", "edit", "view", "create_name", "tags"
-	tagEditMode string // "", "add", "remove"
-	err         error
+	notes    []*note.Note
+	input    textinput.Model
+	textarea textarea.Model
+	tagInput textinput.Model
+	notesApp *app.NotesApp
+	cursor   int
+	selected map[int]struct{}
+	// state is the screen shown and names its key scope, see keyScope:
+	// "list", "help", "view", "edit", "create_name", "create", "rename", "tags",
+	// "move", "export", "problems", "notifications", "vaults", "search",
+	// "palette", "drafts", "confirm_delete", "confirm_merge", "confirm_close",
+	// "confirm_quit" or "confirm_discard"
+	state       string
+	tagEditMode string // "", "add", "delete"
 	newNoteName string
 	showPreview bool
+	splitRatio  float64 // share of the width given to the list beside the preview
+	theme       Theme
+	sortOrder   note.SortOrder
+	session     *state.State
+	config      *config.Config
+	keys        keyMap
+	helpScope   string // key scope the help screen describes
+	width       int
+	height      int
+	offset      int    // index of the first note shown in the list
+	tagFilter   string // only notes with this tag are listed when set
+	viewOffset  int    // first line of the note shown in the view screen
+
+	editorScroll int // first textarea row shown, see trackEditorScroll
+	lastClick    click
+
+	tabs      []tab // notes open in the view and edit screens
+	activeTab int   // index of the tab shown or last shown, -1 for none
+
+	drafts         map[string]string // content of the drafts written this session by id
+	draftSession   string            // Session of the drafts written this session
+	leftoverDrafts []state.Draft     // drafts of earlier sessions not yet recovered or discarded
+	draftCursor    int
+
+	totalNotes int       // notes in the vault, whether listed or not
+	words      wordCount // words of the note under the cursor, see updateWordCount
+
+	notifications      []notification // history, oldest first
+	toasts             []notification // notifications shown on screen
+	lastNotification   int            // id of the newest notification
+	unread             int            // errors added since the history was last opened
+	notificationCursor int
+
+	selectAnchor int  // index of the note a range selection starts from, -1 for none
+	bulk         bool // the current action applies to the selected notes
+	folderInput  textinput.Model
+
+	problemCursor int
+
+	vaultCursor   int
+	vaultApps     map[string]*app.NotesApp // opened vaults by name
+	searchInput   textinput.Model
+	searchResults []app.VaultMatch
+	searchCursor  int
+
+	paletteScope  string // key scope the command palette runs commands in
+	paletteInput  textinput.Model
+	paletteItems  []paletteItem
+	paletteCursor int
+}
+
+// editorFinishedMsg is sent when the external editor exits
+type editorFinishedMsg struct {
+	err error
 }
 
-func NewModel(notesApp *app.NotesApp) Model {
+func NewModel(notesApp *app.NotesApp, cfg *config.Config) Model {
 	ti := textinput.New()
 	ti.Placeholder = "Enter note name..."
 	ti.Width = StandardWidth - StandardTextInputPadding
 
-	ta := textarea.New()
-	ta.Placeholder = "Enter note content..."
-	ta.ShowLineNumbers = false
-	ta.SetWidth(StandardWidth - StandardTextInputPadding)
-	ta.SetHeight(18)
-
 	tagInput := textinput.New()
 	tagInput.Placeholder = "Enter tags (comma-separated)..."
 	tagInput.Width = StandardWidth - StandardTextInputPadding
 
-	return Model{
-		notes:       notesApp.ListAllNotes(),
+	searchInput := textinput.New()
+	searchInput.Placeholder = "Search all vaults..."
+	searchInput.Width = StandardWidth - StandardTextInputPadding
+
+	session, err := state.Load()
+	if err != nil {
+		log.Warn("failed to load session state", "err", err)
+	}
+	// The last sort order used wins over the configured default
+	savedSort := session.SortOrder
+	if savedSort == "" {
+		savedSort = cfg.SortOrder
+	}
+	sortOrder, err := note.ParseSortOrder(savedSort)
+	if err != nil {
+		log.Warn("ignoring saved sort order", "err", err)
+	}
+
+	// Like the sort order, an adjusted split is remembered between sessions
+	splitRatio := session.SplitRatio
+	if splitRatio < minSplitRatio || splitRatio > maxSplitRatio {
+		splitRatio = cfg.SplitRatio
+	}
+
+	// A theme picked in the app wins over the configured one
+	themeName := cfg.Theme
+	if session.Theme != "" {
+		themeName = session.Theme
+	}
+	theme, err := LoadTheme(themeName)
+	if err != nil {
+		log.Warn("falling back to the dark theme", "err", err)
+		theme, _ = LoadTheme("dark")
+	}
+	applyTheme(theme)
+
+	m := Model{
 		input:       ti,
-		textarea:    ta,
 		tagInput:    tagInput,
 		notesApp:    notesApp,
 		selected:    make(map[int]struct{}),
 		state:       "list",
 		showPreview: false,
+		splitRatio:  splitRatio,
+		theme:       theme,
+		sortOrder:   sortOrder,
+		session:     session,
+		config:      cfg,
+		keys:        newKeyMap(cfg.Keybindings),
+		vaultApps:   map[string]*app.NotesApp{cfg.CurrentVault().Name: notesApp},
+		searchInput: searchInput,
+
+		selectAnchor: -1,
+		folderInput:  newFolderInput(),
+		paletteInput: newPaletteInput(),
+		activeTab:    -1,
+		drafts:       make(map[string]string),
+		draftSession: state.NewDraftSession(),
+	}
+	m.textarea = m.newEditor()
+	m.refreshNotes()
+	m.updateWordCount()
+
+	// Edits left unsaved when the app last closed are offered first
+	m.leftoverDrafts, err = state.LoadDrafts()
+	if err != nil {
+		log.Warn("failed to load drafts", "err", err)
+	}
+	if len(m.leftoverDrafts) > 0 {
+		m.openDrafts()
+	}
+	return m
+}
+
+// refreshNotes reloads the note list from the app in the current sort order,
+// keeping the cursor on the same note where possible
+func (m *Model) refreshNotes() {
+	var current string
+	if m.cursor >= 0 && m.cursor < len(m.notes) {
+		current = m.notes[m.cursor].Path
+	}
+	selected, anchor := m.selectedPaths()
+
+	if m.tagFilter != "" {
+		m.notes = m.notesApp.SearchNotes(m.tagFilter, "tag")
+	} else {
+		m.notes = m.notesApp.ListAllNotes()
+	}
+	note.SortNotes(m.notes, m.sortOrder)
+	m.restoreSelection(selected, anchor)
+
+	m.totalNotes = len(m.notes)
+	if m.tagFilter != "" {
+		m.totalNotes = len(m.notesApp.ListAllNotes())
+	}
+
+	defer m.ensureCursorVisible()
+	for i, n := range m.notes {
+		if n.Path == current {
+			m.cursor = i
+			return
+		}
+	}
+	if m.cursor >= len(m.notes) {
+		m.cursor = len(m.notes) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// setSortOrder changes the sort order and remembers it for the next session
+func (m *Model) setSortOrder(order note.SortOrder) {
+	m.sortOrder = order
+	m.refreshNotes()
+
+	m.session.SortOrder = order.String()
+	if err := m.session.Save(); err != nil {
+		m.fail(err)
+	}
+}
+
+// startEditing edits the selected note in its tab
+func (m *Model) startEditing() {
+	m.openTab("edit")
+}
+
+// openNote shows the selected note in its tab
+func (m *Model) openNote() {
+	m.openTab("view")
+}
+
+// filterByTag lists only the notes with the given tag
+func (m *Model) filterByTag(tag string) {
+	m.tagFilter = tag
+	m.state = "list"
+	m.refreshNotes()
+}
+
+// selectByName moves the cursor to the note with the given name
+func (m *Model) selectByName(name string) {
+	for i, n := range m.notes {
+		if strings.EqualFold(n.Name, name) {
+			m.cursor = i
+			m.ensureCursorVisible()
+			return
+		}
+	}
+}
+
+// selectByPath moves the cursor to the note with the given path
+func (m *Model) selectByPath(path string) {
+	for i, n := range m.notes {
+		if n.Path == path {
+			m.cursor = i
+			m.ensureCursorVisible()
+			return
+		}
+	}
+}
+
+// openExternalEditor suspends the TUI and opens the selected note in the configured editor.
+// Notes that are not plain files, such as those in a SQLite vault, are edited
+// through a temporary file and saved back when the editor exits.
+func (m Model) openExternalEditor() tea.Cmd {
+	if len(m.notes) == 0 {
+		return nil
+	}
+
+	n := m.notes[m.cursor]
+	filePath := n.Path
+	tempFile := ""
+	original := ""
+	if _, err := os.Stat(n.Path); err != nil {
+		original, err = m.notesApp.GetContent(n)
+		if err != nil {
+			return func() tea.Msg { return editorFinishedMsg{err: err} }
+		}
+		f, err := os.CreateTemp("", "notes-app-*"+note.DefaultExtension)
+		if err != nil {
+			return func() tea.Msg { return editorFinishedMsg{err: err} }
+		}
+		_, err = f.WriteString(original)
+		f.Close()
+		if err != nil {
+			return func() tea.Msg { return editorFinishedMsg{err: err} }
+		}
+		filePath, tempFile = f.Name(), f.Name()
 	}
+
+	return tea.ExecProcess(m.editorCommand(filePath), func(err error) tea.Msg {
+		if tempFile == "" {
+			return editorFinishedMsg{err: err}
+		}
+		defer os.Remove(tempFile)
+		if err != nil {
+			return editorFinishedMsg{err: err}
+		}
+
+		content, err := os.ReadFile(tempFile)
+		if err == nil && string(content) != original {
+			err = m.notesApp.UpdateNoteContent(n.Path, string(content))
+		}
+		return editorFinishedMsg{err: err}
+	})
+}
+
+// editorCommand builds the command that opens a file in the configured editor
+func (m Model) editorCommand(filePath string) *exec.Cmd {
+	args := strings.Fields(m.config.Editor)
+	args = append(args, filePath)
+	return exec.Command(args[0], args[1:]...)
 }
 
 func (m Model) Init() tea.Cmd {
-	return textinput.Blink
+	return tea.Batch(textinput.Blink, draftTick())
 }
 
+// Update handles a message, keeps the shown tab up to date and schedules
+// hiding the toasts it adds
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	last := m.lastNotification
+	updated, cmd := m.update(msg)
+	next, ok := updated.(Model)
+	if !ok {
+		return updated, cmd
+	}
+	next.stashTab()
+	next.updateWordCount()
+	if next.lastNotification != last {
+		return next, tea.Batch(cmd, next.expireToasts(last))
+	}
+	return next, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		// Global shortcuts that work in any state
-		switch msg.String() {
-		case "ctrl+c", "ctrl+q":
-			if m.state == "list" {
-				return m, tea.Quit
+		if m.matches(msg, actionQuit) {
+			if m.state == "list" && len(m.unsavedTabs()) > 0 {
+				m.state = "confirm_quit"
+				return m, nil
+			}
+			if m.state == "list" || m.state == "confirm_quit" {
+				return m, m.quit()
+			}
+			if m.state == "create" && m.textarea.Value() != "" {
+				m.state = "confirm_discard"
+				return m, nil
 			}
 			m.state = "list"
 			return m, nil
 		}
 
+		if m.matches(msg, actionDismiss) && len(m.toasts) > 0 {
+			m.dismissToast()
+			return m, nil
+		}
+
+		if m.matches(msg, actionPalette) {
+			m.openPalette()
+			return m, textinput.Blink
+		}
+
 		// State-specific shortcuts
 		switch m.state {
 		case "list":
-			switch msg.String() {
-			case "ctrl+h", "?":
+			switch {
+			case m.matches(msg, actionHelp):
+				m.helpScope = m.keyScope()
 				m.state = "help"
-			case "ctrl+t", "t":
+			case m.matches(msg, actionTags):
 				if len(m.notes) > 0 {
+					m.startBulk()
 					m.state = "tags"
 					m.tagEditMode = ""
 				}
-			case "ctrl+n", "n":
+			case m.matches(msg, actionNew):
 				m.state = "create_name"
 				m.input.Focus()
-			case "ctrl+e", "e":
+			case m.matches(msg, actionRename):
 				if len(m.notes) > 0 {
-					m.state = "edit"
-					m.textarea.SetValue(m.notes[m.cursor].Content)
-					m.textarea.Focus()
+					m.state = "rename"
+					m.input.SetValue(m.notes[m.cursor].Name)
+					m.input.CursorEnd()
+					m.input.Focus()
 				}
-			case "ctrl+d", "d":
+			case m.matches(msg, actionEdit):
 				if len(m.notes) > 0 {
+					m.startEditing()
+				}
+			case m.matches(msg, actionDelete):
+				if len(m.notes) > 0 {
+					m.startBulk()
 					m.state = "confirm_delete"
 				}
 
-			case "enter":
+			case m.matches(msg, actionOpen):
 				if len(m.notes) > 0 {
-					m.state = "view"
+					m.openNote()
 				}
-			case "up", "k":
-				if m.cursor > 0 {
-					m.cursor--
+			case m.matches(msg, actionUp):
+				m.moveCursor(-1)
+			case m.matches(msg, actionDown):
+				m.moveCursor(1)
+			case m.matches(msg, actionPageUp):
+				m.moveCursor(-m.visibleRows())
+			case m.matches(msg, actionPageDown):
+				m.moveCursor(m.visibleRows())
+			case m.matches(msg, actionTop):
+				m.moveCursor(-len(m.notes))
+			case m.matches(msg, actionBottom):
+				m.moveCursor(len(m.notes))
+			case m.matches(msg, actionPreview):
+				m.showPreview = !m.showPreview
+				m.ensureCursorVisible()
+			case m.matches(msg, actionShrinkList):
+				m.adjustSplit(-splitRatioStep)
+			case m.matches(msg, actionGrowList):
+				m.adjustSplit(splitRatioStep)
+			case m.matches(msg, actionSort):
+				m.setSortOrder(m.sortOrder.NextField())
+			case m.matches(msg, actionReverseSort):
+				m.setSortOrder(m.sortOrder.Reversed())
+			case m.matches(msg, actionExternalEditor):
+				return m, m.openExternalEditor()
+			case m.matches(msg, actionProblems):
+				m.state = "problems"
+				m.problemCursor = 0
+			case m.matches(msg, actionNotifications):
+				m.openNotifications()
+			case m.matches(msg, actionTheme):
+				m.nextTheme()
+			case m.matches(msg, actionVaults):
+				m.state = "vaults"
+				m.vaultCursor = 0
+				for i, name := range m.config.VaultNames() {
+					if name == m.config.CurrentVault().Name {
+						m.vaultCursor = i
+					}
 				}
-			case "down", "j":
-				if m.cursor < len(m.notes)-1 {
-					m.cursor++
+			case m.matches(msg, actionSearch):
+				m.startSearch()
+				return m, textinput.Blink
+			case m.matches(msg, actionClearFilter):
+				if m.tagFilter != "" {
+					m.tagFilter = ""
+					m.refreshNotes()
 				}
-			case " ":
-				m.showPreview = !m.showPreview
+			case m.matches(msg, actionToggleSelect):
+				m.toggleSelect(m.cursor)
+				m.moveCursor(1)
+			case m.matches(msg, actionSelectRange):
+				m.selectRange()
+			case m.matches(msg, actionSelectAll):
+				m.selectAll()
+			case m.matches(msg, actionSelectNone):
+				m.clearSelection()
+			case m.matches(msg, actionInvertSelect):
+				m.invertSelection()
+			case m.matches(msg, actionMove):
+				if len(m.notes) > 0 {
+					m.startFolderInput("move", "Folder, e.g. projects/2024")
+					return m, textinput.Blink
+				}
+			case m.matches(msg, actionExport):
+				if len(m.notes) > 0 {
+					m.startFolderInput("export", "Folder outside the vault, e.g. ~/export")
+					return m, textinput.Blink
+				}
+			case m.matches(msg, actionMerge):
+				m.startMerge()
+			case m.matches(msg, actionNextTab):
+				m.returnToTabs()
+			case m.matches(msg, actionDrafts):
+				m.openDrafts()
 			}
 
 		case "view":
-			switch msg.String() {
-			case "ctrl+h", "?":
+			switch {
+			case m.matches(msg, actionUp):
+				m.scrollView(-1)
+			case m.matches(msg, actionDown):
+				m.scrollView(1)
+			case m.matches(msg, actionPageUp):
+				m.scrollView(-m.viewRows())
+			case m.matches(msg, actionPageDown):
+				m.scrollView(m.viewRows())
+			case m.matches(msg, actionHelp):
+				m.helpScope = m.keyScope()
 				m.state = "help"
-			case "ctrl+t", "t":
+			case m.matches(msg, actionTags):
 				if len(m.notes) > 0 {
+					m.bulk = false
 					m.state = "tags"
 					m.tagEditMode = ""
 				}
-			case "ctrl+e", "e":
+			case m.matches(msg, actionEdit):
 				if len(m.notes) > 0 {
-					m.state = "edit"
-					m.textarea.SetValue(m.notes[m.cursor].Content)
-					m.textarea.Focus()
+					m.startEditing()
 				}
-			case "ctrl+d", "d":
+			case m.matches(msg, actionDelete):
 				if len(m.notes) > 0 {
+					m.bulk = false
 					m.state = "confirm_delete"
 				}
-
-			case "esc":
+			case m.matches(msg, actionExternalEditor):
+				return m, m.openExternalEditor()
+			case m.matches(msg, actionNextTab):
+				m.switchTab(1)
+			case m.matches(msg, actionPrevTab):
+				m.switchTab(-1)
+			case m.matches(msg, actionCloseTab):
+				m.closeActiveTab()
+
+			case m.matches(msg, actionBack):
 				m.state = "list"
 			}
 
 		case "create_name":
-			switch msg.String() {
-			case "enter":
+			switch {
+			case m.matches(msg, actionSubmit):
 				m.newNoteName = m.input.Value()
 				if m.newNoteName != "" {
-					if m.notesApp.NoteExists(m.newNoteName) {
-						m.err = fmt.Errorf("note with name '%s' already exists", m.newNoteName)
-						m.input.SetValue("")
+					if m.nameError(m.newNoteName, "") != nil {
+						// The problem is shown below the input
+						m.newNoteName = ""
 						return m, nil
 					}
 					m.state = "create"
-					m.textarea.Reset()
+					// The textarea may still be the editor of a tab
+					m.textarea = m.newEditor()
 					m.textarea.Focus()
+					m.editorScroll = 0
 					m.input.Reset()
 				}
-			case "esc":
+			case m.matches(msg, actionBack):
 				m.state = "list"
 				m.input.Reset()
 				m.newNoteName = ""
 			}
 			m.input, cmd = m.input.Update(msg)
 
+		case "rename":
+			switch {
+			case m.matches(msg, actionSubmit):
+				if newName := m.input.Value(); newName != "" && len(m.notes) > 0 {
+					if m.nameError(newName, m.notes[m.cursor].Name) != nil {
+						return m, nil
+					}
+					oldPath := m.notes[m.cursor].Path
+					if err := m.notesApp.RenameNote(oldPath, newName); err != nil {
+						m.fail(err)
+						return m, nil
+					}
+					m.refreshNotes()
+					m.selectByName(newName)
+					m.retargetTab(oldPath, m.notes[m.cursor].Path, m.notes[m.cursor].Name)
+					m.info("Renamed to '%s'", strings.TrimSpace(newName))
+					m.state = "list"
+					m.input.Reset()
+				}
+			case m.matches(msg, actionBack):
+				m.state = "list"
+				m.input.Reset()
+			}
+			m.input, cmd = m.input.Update(msg)
+
 		case "create":
-			switch msg.String() {
-			case "ctrl+s":
+			switch {
+			case m.matches(msg, actionSave):
 				if m.newNoteName != "" {
 					err := m.notesApp.CreateNote(m.newNoteName, m.textarea.Value())
 					if err != nil {
-						m.err = err
+						m.fail(err)
 					} else {
-						m.notes = m.notesApp.ListAllNotes()
-						m.state = "list"
-						m.textarea.Reset()
-						m.newNoteName = ""
+						m.refreshNotes()
+						m.selectByName(m.newNoteName)
+						m.info("Created '%s'", m.newNoteName)
+						m.finishNewNote()
 					}
 				}
-			case "esc":
-				m.state = "list"
-				m.textarea.Reset()
-				m.newNoteName = ""
+			case m.matches(msg, actionBack):
+				if m.textarea.Value() != "" {
+					m.state = "confirm_discard"
+					return m, nil
+				}
+				m.finishNewNote()
 			}
 			m.textarea, cmd = m.textarea.Update(msg)
+			m.trackEditorScroll()
 
 		case "edit":
-			switch msg.String() {
-			case "ctrl+s":
-				if len(m.notes) > 0 {
-					err := m.notesApp.UpdateNoteContent(m.notes[m.cursor].Path, m.textarea.Value())
-					if err != nil {
-						m.err = err
-					} else {
-						m.notes = m.notesApp.ListAllNotes()
-						m.state = "list"
-						m.textarea.Reset()
-					}
+			switch {
+			case m.matches(msg, actionSave):
+				t := m.tabs[m.activeTab]
+				if err := m.notesApp.UpdateNoteContent(t.path, m.textarea.Value()); err != nil {
+					m.fail(err)
+					return m, nil
 				}
-			case "esc":
+				m.info("Saved '%s'", t.name)
+				m.tabs[m.activeTab] = tab{path: t.path, name: t.name, mode: "view", viewOffset: t.viewOffset}
+				m.saveDrafts()
+				m.refreshNotes()
+				m.state = "list"
+				return m, nil
+			case m.matches(msg, actionNextTab):
+				m.switchTab(1)
+				return m, nil
+			case m.matches(msg, actionPrevTab):
+				m.switchTab(-1)
+				return m, nil
+			case m.matches(msg, actionCloseTab):
+				m.closeActiveTab()
+				return m, nil
+			case m.matches(msg, actionBack):
+				// The edits stay in the tab until it is saved or closed
 				m.state = "list"
-				m.textarea.Reset()
+				return m, nil
 			}
 			m.textarea, cmd = m.textarea.Update(msg)
+			m.trackEditorScroll()
 
 		case "confirm_delete":
-			switch msg.String() {
-			case "y":
-				err := m.notesApp.DeleteNote(m.notes[m.cursor].Path)
-				if err != nil {
-					m.err = err
-				} else {
-					m.notes = m.notesApp.ListAllNotes()
-					if m.cursor >= len(m.notes) {
-						m.cursor = len(m.notes) - 1
-					}
-					m.state = "list"
-				}
-			case "n", "esc":
+			switch {
+			case m.matches(msg, actionConfirm):
+				m.deleteTargets()
+			case m.matches(msg, actionBack):
 				m.state = "list"
 			}
 
 		case "tags":
 			if m.tagEditMode != "" {
-				switch msg.String() {
-				case "esc":
+				switch {
+				case m.matches(msg, actionBack):
 					m.tagInput.Reset()
 					m.tagEditMode = ""
-				case "enter":
+				case m.matches(msg, actionSubmit):
 					tags := strings.Split(m.tagInput.Value(), ",")
 					var validTags []string
 					for _, tag := range tags {
@@ -233,18 +653,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 
 					if len(validTags) > 0 {
-						note := m.notes[m.cursor]
+						what := m.describeTargets()
+						var changed int
 						var err error
-						if m.tagEditMode == "add" {
-							err = m.notesApp.AddTagsToNote(note.Path, validTags)
-						} else {
-							err = m.notesApp.RemoveTagsFromNote(note.Path, validTags)
+						switch {
+						case m.bulk && m.tagEditMode == "add":
+							changed, err = m.notesApp.AddTagsToNotes(m.targetPaths(), validTags)
+						case m.bulk:
+							changed, err = m.notesApp.RemoveTagsFromNotes(m.targetPaths(), validTags)
+						case m.tagEditMode == "add":
+							changed, err = 1, m.notesApp.AddTagsToNote(m.notes[m.cursor].Path, validTags)
+						default:
+							changed, err = 1, m.notesApp.RemoveTagsFromNote(m.notes[m.cursor].Path, validTags)
 						}
-						if err != nil {
-							m.err = err
+						if err != nil && !m.bulk {
+							m.fail(err)
 						} else {
-							m.notes = m.notesApp.ListAllNotes()
-							m.state = "list"
+							if err != nil {
+								what = countNotes(changed)
+							}
+							if changed > 0 && m.tagEditMode == "add" {
+								m.info("Tagged %s", what)
+							} else if changed > 0 {
+								m.info("Removed tags from %s", what)
+							}
+							m.finishBulk(err)
 							m.tagInput.Reset()
 							m.tagEditMode = ""
 						}
@@ -253,177 +686,249 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.tagInput, cmd = m.tagInput.Update(msg)
 				}
 			} else {
-				switch msg.String() {
-				case "ctrl+a":
+				switch {
+				case m.matches(msg, actionAddTags):
 					m.tagEditMode = "add"
 					m.tagInput.Focus()
 					m.tagInput.SetValue("")
-				case "ctrl+d":
-					if len(m.notes[m.cursor].Metadata.Tags) > 0 {
+				case m.matches(msg, actionDeleteTags):
+					if len(m.targetTags()) > 0 {
 						m.tagEditMode = "delete"
 						m.tagInput.Focus()
 						m.tagInput.SetValue("")
 					}
-				case "esc":
+				case m.matches(msg, actionBack):
 					m.state = "list"
 					m.tagInput.Reset()
 				}
 			}
 
+		case "move":
+			return m.updateMove(msg)
+
+		case "export":
+			return m.updateExport(msg)
+
+		case "confirm_merge":
+			return m.updateMerge(msg)
+
+		case "problems":
+			return m.updateProblems(msg)
+
+		case "notifications":
+			return m.updateNotifications(msg)
+
+		case "vaults":
+			return m.updateVaults(msg)
+
+		case "search":
+			return m.updateSearch(msg)
+
+		case "palette":
+			return m.updatePalette(msg)
+
+		case "confirm_close":
+			return m.updateConfirmClose(msg)
+
+		case "confirm_quit":
+			return m.updateConfirmQuit(msg)
+
+		case "confirm_discard":
+			return m.updateConfirmDiscard(msg)
+
+		case "drafts":
+			return m.updateDrafts(msg)
+
 		case "help":
-			switch msg.String() {
-			case "esc":
-				m.state = "list"
+			switch {
+			case m.matches(msg, actionBack):
+				m.state = m.helpScope
 			}
 		}
 
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+
+	case draftTickMsg:
+		m.saveDrafts()
+		return m, draftTick()
+
+	case toastExpiredMsg:
+		m.hideToast(msg.id)
+		return m, nil
+
+	case editorFinishedMsg:
+		if msg.err != nil {
+			m.fail(msg.err)
+		} else if err := m.notesApp.RefreshIndex(); err != nil {
+			m.fail(err)
+		}
+		m.refreshNotes()
+		m.syncTabs()
+		return m, nil
+
 	case tea.WindowSizeMsg:
-		m.textarea.SetWidth(msg.Width - 4)
+		m.width, m.height = msg.Width, msg.Height
+		m.textarea.SetWidth(m.editorWidth())
+		for i, t := range m.tabs {
+			if t.mode == "edit" {
+				m.tabs[i].editor.SetWidth(m.editorWidth())
+			}
+		}
+		m.ensureCursorVisible()
 		return m, nil
 	}
 
 	return m, cmd
 }
 
+// View renders the current screen with the visible toasts on top
 func (m Model) View() string {
-	var s strings.Builder
-
-	if m.err != nil {
-		s.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v\n\n", m.err)))
+	if m.state == "palette" {
+		return m.overlayToasts(m.renderPalette())
 	}
+	return m.overlayToasts(m.renderScreen())
+}
+
+// renderScreen renders the current screen with its help and status bars
+func (m Model) renderScreen() string {
+	var s strings.Builder
 
 	switch m.state {
 	case "help":
-		s.WriteString(titleStyle.Render("Help") + "\n\n")
-		s.WriteString(helpStyle.Render(`
-Commands:
-  ctrl+h, ?	- Show this help
-  j, ↓		 - Move down
-  k, ↑		 - Move up
-  n    		- Create new note
-  e			- Edit selected note
-  d			- Delete selected note
-  t			- Manage tags
-  space        - Toggle preview
-  enter        - View note
-  ctrl+s       - Save (in edit/create mode)
-  esc          - Back/cancel
-  ctrl+q		- Quit application
-
-Tag Management:
-  ctrl+a    - Add tags
-  ctrl+d    - Delete tags
-  enter     - Confirm tags
-  esc       - Cancel
-`))
+		s.WriteString(m.renderHelp())
 
 	case "create_name":
 		s.WriteString(titleStyle.Render("New Note") + "\n\n")
-		s.WriteString("Enter note name (press enter when done):\n")
+		s.WriteString("Enter note name:\n")
 		s.WriteString(inputStyle.Render(m.input.View()))
+		s.WriteString(m.renderNameError(""))
+
+	case "rename":
+		if len(m.notes) > 0 {
+			s.WriteString(titleStyle.Render("Rename: "+m.notes[m.cursor].Name) + "\n\n")
+			s.WriteString("Enter new name:\n")
+			s.WriteString(inputStyle.Render(m.input.View()))
+			s.WriteString(m.renderNameError(m.notes[m.cursor].Name))
+		}
 
 	case "create":
-		s.WriteString(titleStyle.Render("New Note: "+m.newNoteName) + "\n\n")
-		s.WriteString("Enter note content (ctrl+s to save, esc to cancel):\n")
+		s.WriteString(m.editorHeader())
 		s.WriteString(textareaStyle.Render(m.textarea.View()))
 
 	case "edit":
-		if len(m.notes) > 0 {
-			s.WriteString(titleStyle.Render("Editing: "+m.notes[m.cursor].Name) + "\n\n")
-			s.WriteString(textareaStyle.Render(m.textarea.View()))
-			s.WriteString("\n" + helpStyle.Render("Press ctrl+s to save, esc to cancel"))
-		}
+		s.WriteString(m.renderTabBar())
+		s.WriteString(m.editorHeader())
+		s.WriteString(textareaStyle.Render(m.textarea.View()))
 	case "confirm_delete":
 		if len(m.notes) > 0 {
-			note := m.notes[m.cursor]
 			s.WriteString(titleStyle.Render("Confirm Delete") + "\n\n")
-			s.WriteString(fmt.Sprintf("Are you sure you want to delete note '%s'?\n\n", note.Name))
-			s.WriteString(helpStyle.Render("Press 'y' to confirm, 'n' or 'esc' to cancel"))
+			if m.bulk {
+				s.WriteString(fmt.Sprintf("Are you sure you want to delete these %d notes?\n\n", len(m.targetNotes())))
+				s.WriteString(m.renderTargets())
+			} else {
+				s.WriteString(fmt.Sprintf("Are you sure you want to delete note '%s'?\n", m.notes[m.cursor].Name))
+			}
 		}
 
 	case "view":
 		if len(m.notes) > 0 {
-			note := m.notes[m.cursor]
-			s.WriteString(titleStyle.Render(note.Name))
-			if len(note.Metadata.Tags) > 0 {
-				s.WriteString(" " + tagStyle.Render(fmt.Sprintf("[%s]", strings.Join(note.Metadata.Tags, ", "))))
-			}
-			s.WriteString("\n\n")
-			s.WriteString(note.Content)
-			s.WriteString("\n\n" + helpStyle.Render("Press esc to go back"))
+			s.WriteString(m.renderTabBar())
+			s.WriteString(m.renderNote())
 		}
 
+	case "confirm_close":
+		s.WriteString(m.renderConfirmClose())
+
+	case "confirm_quit":
+		s.WriteString(m.renderConfirmQuit())
+
+	case "confirm_discard":
+		s.WriteString(m.renderConfirmDiscard())
+
+	case "drafts":
+		s.WriteString(m.renderDrafts())
+
 	case "tags":
 		if len(m.notes) > 0 {
-			note := m.notes[m.cursor]
-			s.WriteString(titleStyle.Render("Tags for: "+note.Name) + "\n\n")
+			s.WriteString(titleStyle.Render("Tags for: "+m.describeTargets()) + "\n\n")
 
-			if len(note.Metadata.Tags) > 0 {
-				s.WriteString("Current tags: " + tagStyle.Render(strings.Join(note.Metadata.Tags, ", ")) + "\n\n")
-				s.WriteString(helpStyle.Render("Press 'ctrl+a' to add tags, 'ctrl+d' to delete tags, esc to go back") + "\n\n")
+			if tags := m.targetTags(); len(tags) > 0 {
+				s.WriteString("Current tags: " + tagStyle.Render(strings.Join(tags, ", ")) + "\n\n")
 			} else {
 				s.WriteString("No tags\n\n")
-				s.WriteString(helpStyle.Render("Press 'ctrl+a' to add tags, esc to go back") + "\n\n")
 			}
 
 			if m.tagEditMode == "add" {
 				s.WriteString("Add tags (comma-separated):\n")
 				s.WriteString(inputStyle.Render(m.tagInput.View()) + "\n")
-				s.WriteString(helpStyle.Render("Press enter to confirm"))
 			} else if m.tagEditMode == "delete" {
 				s.WriteString("Delete tags (comma-separated):\n")
 				s.WriteString(inputStyle.Render(m.tagInput.View()) + "\n")
-				s.WriteString(helpStyle.Render("Press enter to confirm"))
 			}
 		}
 
-	case "list":
-		// s.WriteString(titleStyle.Render("📝 Notes") + "\n\n")
-
-		if len(m.notes) == 0 {
-			s.WriteString(listStyle.Render("No notes found. Press 'ctrl+n' to create one."))
-		} else {
-			var listContent strings.Builder
-			for i, note := range m.notes {
-				cursor := " "
-				if m.cursor == i {
-					cursor = ">"
-				}
+	case "move":
+		s.WriteString(m.renderMove())
 
-				noteText := fmt.Sprintf("%s %s",
-					cursor,
-					note.Name)
+	case "export":
+		s.WriteString(m.renderExport())
 
-				if len(note.Metadata.Tags) > 0 {
-					noteText += " " + tagStyle.Render(
-						fmt.Sprintf("[%s]", strings.Join(note.Metadata.Tags, ", ")),
-					)
-				}
+	case "confirm_merge":
+		s.WriteString(m.renderMerge())
 
-				if m.cursor == i {
-					listContent.WriteString(selectedNoteStyle.Render(noteText))
-				} else {
-					listContent.WriteString(noteStyle.Render(noteText))
-				}
-				listContent.WriteString("\n")
-			}
-			s.WriteString(listStyle.Render(listContent.String()))
+	case "problems":
+		s.WriteString(m.renderProblems())
 
-			if m.showPreview && len(m.notes) > 0 {
-				note := m.notes[m.cursor]
-				s.WriteString("\n" + previewTitleStyle.Render("Preview"))
+	case "notifications":
+		s.WriteString(m.renderNotifications())
 
-				content := note.Content
-				if len(content) > 200 {
-					content = content[:200] + "..."
-				}
+	case "vaults":
+		s.WriteString(m.renderVaults())
 
-				s.WriteString("\n" + previewStyle.Render(content))
-			}
-		}
+	case "search":
+		s.WriteString(m.renderSearch())
 
-		s.WriteString("\n" + helpStyle.Render("Press '?' for help, space to toggle preview"))
+	case "list":
+		s.WriteString(m.renderList())
 	}
 
-	return mainStyle.Render(s.String())
+	s.WriteString("\n" + m.renderShortHelp())
+	return m.placeStatusBar(mainStyle.Render(s.String()))
+}
+
+// editorHeader renders the lines above the textarea when writing or editing a note
+func (m Model) editorHeader() string {
+	if m.state == "create" {
+		return titleStyle.Render("New Note: "+m.newNoteName) + "\n\n" + "Enter note content:\n"
+	}
+	return titleStyle.Render("Editing: "+m.tabs[m.activeTab].name) + "\n\n"
+}
+
+// nameError checks a name typed for a new or renamed note. current is the
+// name of the note being renamed, which may keep its name with another case.
+func (m Model) nameError(name, current string) error {
+	name = strings.TrimSpace(name)
+	if err := storage.ValidateNoteName(name); err != nil {
+		var nameErr *storage.NameError
+		if errors.As(err, &nameErr) {
+			return errors.New(nameErr.Reason)
+		}
+		return err
+	}
+	if !strings.EqualFold(name, current) && m.notesApp.NoteExists(name) {
+		return fmt.Errorf("a note named '%s' already exists", name)
+	}
+	return nil
+}
+
+// renderNameError shows why the name in the input cannot be used, if it cannot
+func (m Model) renderNameError(current string) string {
+	if m.input.Value() == "" {
+		return ""
+	}
+	if err := m.nameError(m.input.Value(), current); err != nil {
+		return "\n" + errorStyle.Render(err.Error())
+	}
+	return ""
 }
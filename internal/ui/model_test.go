@@ -0,0 +1,313 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"notes-app/internal/app"
+	"notes-app/internal/config"
+	"notes-app/internal/state"
+	"notes-app/internal/storage"
+)
+
+// newTestModel returns a model on an in-memory vault holding the given notes
+func newTestModel(t *testing.T, notes ...string) Model {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	a, err := app.Open(storage.NewMemoryStorage(""), "")
+	if err != nil {
+		t.Fatalf("failed to open memory vault: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+	for _, name := range notes {
+		if err := a.CreateNote(name, "content of "+name); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Theme = "dark"
+	cfg.SortOrder = "name"
+	return NewModel(a, cfg)
+}
+
+// pressKey sends the key with the given name, as used in key bindings
+func pressKey(t *testing.T, m Model, name string) Model {
+	t.Helper()
+	msg, ok := keyMsg(name)
+	if !ok {
+		t.Fatalf("unknown key %q", name)
+	}
+	return update(m, msg)
+}
+
+// press sends keys to the model. A key starting with ':' types the text after it.
+func press(t *testing.T, m Model, keys ...string) Model {
+	t.Helper()
+	for _, k := range keys {
+		if len(k) > 1 && k[0] == ':' {
+			for _, r := range k[1:] {
+				m = pressKey(t, m, string(r))
+			}
+			continue
+		}
+		m = pressKey(t, m, k)
+	}
+	return m
+}
+
+// update sends one message to the model
+func update(m Model, msg tea.Msg) Model {
+	next, _ := m.Update(msg)
+	return next.(Model)
+}
+
+// listedNames returns the names of the listed notes in list order
+func listedNames(m Model) []string {
+	var names []string
+	for _, n := range m.notes {
+		names = append(names, n.Name)
+	}
+	return names
+}
+
+func TestModelNoteActions(t *testing.T) {
+	tests := []struct {
+		name  string
+		notes []string
+		keys  []string
+		want  []string
+		state string
+	}{
+		{
+			name:  "create",
+			notes: []string{"a"},
+			keys:  []string{"n", ":b", "enter", ":text", "ctrl+s"},
+			want:  []string{"a", "b"},
+			state: "list",
+		},
+		{
+			name:  "create cancelled without text",
+			notes: []string{"a"},
+			keys:  []string{"n", ":b", "enter", "esc"},
+			want:  []string{"a"},
+			state: "list",
+		},
+		{
+			name:  "create cancelled with text asks first",
+			notes: []string{"a"},
+			keys:  []string{"n", ":b", "enter", ":text", "esc"},
+			want:  []string{"a"},
+			state: "confirm_discard",
+		},
+		{
+			name:  "rename",
+			notes: []string{"a", "b"},
+			keys:  []string{"r", "ctrl+u", ":c", "enter"},
+			want:  []string{"b", "c"},
+			state: "list",
+		},
+		{
+			name:  "rename onto existing name is refused",
+			notes: []string{"a", "b"},
+			keys:  []string{"r", "ctrl+u", ":b", "enter"},
+			want:  []string{"a", "b"},
+			state: "rename",
+		},
+		{
+			name:  "delete",
+			notes: []string{"a", "b"},
+			keys:  []string{"down", "d", "y"},
+			want:  []string{"a"},
+			state: "list",
+		},
+		{
+			name:  "delete cancelled",
+			notes: []string{"a", "b"},
+			keys:  []string{"d", "n"},
+			want:  []string{"a", "b"},
+			state: "list",
+		},
+		{
+			name:  "delete from the command palette",
+			notes: []string{"a", "b"},
+			keys:  []string{"ctrl+p", ":delete", "enter", "y"},
+			want:  []string{"b"},
+			state: "list",
+		},
+		{
+			name:  "delete selected notes",
+			notes: []string{"a", "b", "c"},
+			keys:  []string{"x", "x", "d", "y"},
+			want:  []string{"c"},
+			state: "list",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := press(t, newTestModel(t, tt.notes...), tt.keys...)
+			if got := listedNames(m); !slices.Equal(got, tt.want) {
+				t.Errorf("notes = %v, want %v", got, tt.want)
+			}
+			if m.state != tt.state {
+				t.Errorf("state = %q, want %q", m.state, tt.state)
+			}
+		})
+	}
+}
+
+func TestModelTags(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{name: "add", keys: []string{"t", "ctrl+a", ":work, urgent", "enter"}, want: []string{"work", "urgent"}},
+		{name: "add cancelled", keys: []string{"t", "ctrl+a", ":work", "esc"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := press(t, newTestModel(t, "a"), tt.keys...)
+			n, err := m.notesApp.GetNote("a")
+			if err != nil {
+				t.Fatalf("failed to get note: %v", err)
+			}
+			if !slices.Equal(n.Metadata.Tags, tt.want) {
+				t.Errorf("tags = %v, want %v", n.Metadata.Tags, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelTabs(t *testing.T) {
+	m := press(t, newTestModel(t, "a", "b"), "e", ":edit", "esc", "down", "enter")
+	if len(m.tabs) != 2 || m.state != "view" {
+		t.Fatalf("tabs = %d in state %q, want 2 in view", len(m.tabs), m.state)
+	}
+	if got := m.unsavedTabs(); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("unsaved tabs = %v, want [a]", got)
+	}
+
+	// Switching back finds the edit where it was left
+	m = pressKey(t, m, "tab")
+	if m.state != "edit" || m.textarea.Value() != "content of aedit" {
+		t.Errorf("state %q with text %q, want the edit of a", m.state, m.textarea.Value())
+	}
+}
+
+func TestModelWordCount(t *testing.T) {
+	m := newTestModel(t, "a", "b")
+	if !slices.Contains(m.noteStatus(), "3 words") {
+		t.Fatalf("status = %v, want 3 words", m.noteStatus())
+	}
+
+	// The count follows edits of the note under the cursor
+	m = press(t, m, "e", ": and more", "ctrl+s")
+	if !slices.Contains(m.noteStatus(), "5 words") {
+		t.Errorf("status after edit = %v, want 5 words", m.noteStatus())
+	}
+
+	// A cursor left past the end of the list shows no note
+	m.notes = m.notes[:1]
+	m.cursor = 1
+	if got := m.noteStatus(); got != nil {
+		t.Errorf("status past the last note = %v, want none", got)
+	}
+}
+
+func TestModelDrafts(t *testing.T) {
+	drafts := func() []string {
+		t.Helper()
+		loaded, err := state.LoadDrafts()
+		if err != nil {
+			t.Fatalf("failed to load drafts: %v", err)
+		}
+		var contents []string
+		for _, d := range loaded {
+			contents = append(contents, d.Content)
+		}
+		slices.Sort(contents)
+		return contents
+	}
+
+	// A session that ends without saving its edit leaves a draft behind
+	first := press(t, newTestModel(t, "a"), "e", ": first")
+	first = update(first, draftTickMsg{})
+
+	m := NewModel(first.notesApp, first.config)
+	if m.state != "drafts" || len(m.leftoverDrafts) != 1 {
+		t.Fatalf("state %q with %d leftover drafts, want the drafts screen with 1", m.state, len(m.leftoverDrafts))
+	}
+
+	// Editing and saving the same note never touches the leftover draft
+	m = press(t, m, "esc", "e", ": second")
+	m = update(m, draftTickMsg{})
+	if got, want := drafts(), []string{"content of a first", "content of a second"}; !slices.Equal(got, want) {
+		t.Errorf("drafts while editing = %q, want %q", got, want)
+	}
+	m = press(t, m, "ctrl+s")
+	m = update(m, draftTickMsg{})
+	if got, want := drafts(), []string{"content of a first"}; !slices.Equal(got, want) {
+		t.Errorf("drafts after saving = %q, want %q", got, want)
+	}
+
+	// Recovering the draft moves it into this session, which removes it
+	// once the recovered text is saved
+	m = press(t, m, "esc", "D", "enter")
+	if m.state != "edit" || m.textarea.Value() != "content of a first" {
+		t.Fatalf("state %q with text %q, want the recovered draft in the editor", m.state, m.textarea.Value())
+	}
+	if got, want := drafts(), []string{"content of a first"}; !slices.Equal(got, want) {
+		t.Errorf("drafts after recovering = %q, want %q", got, want)
+	}
+	m = press(t, m, "ctrl+s")
+	m = update(m, draftTickMsg{})
+	if got := drafts(); got != nil {
+		t.Errorf("drafts after saving the recovered text = %q, want none", got)
+	}
+}
+
+func TestModelMouseWheel(t *testing.T) {
+	wheel := func(m Model, button tea.MouseButton, times int) Model {
+		for range times {
+			m = update(m, tea.MouseMsg{Button: button, Action: tea.MouseActionPress})
+		}
+		return m
+	}
+
+	vaults := func(m Model) int { return m.vaultCursor }
+	tests := []struct {
+		name   string
+		vaults []string
+		key    string
+		cursor func(m Model) int
+		last   int
+	}{
+		{"one vault", nil, "V", vaults, 0},
+		{"several vaults", []string{"work", "shared"}, "V", vaults, 2},
+		{"search without matches", nil, "/", func(m Model) int { return m.searchCursor }, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, "a")
+			m.config.Vaults = make(map[string]config.Vault)
+			for _, name := range tt.vaults {
+				m.config.Vaults[name] = config.Vault{Path: t.TempDir()}
+			}
+			m = press(t, m, tt.key)
+			m = wheel(m, tea.MouseButtonWheelDown, 3)
+			if got := tt.cursor(m); got != tt.last {
+				t.Errorf("cursor after scrolling down = %d, want %d", got, tt.last)
+			}
+			m = wheel(m, tea.MouseButtonWheelUp, 3)
+			if got := tt.cursor(m); got != 0 {
+				t.Errorf("cursor after scrolling up = %d, want 0", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// doubleClickInterval is the longest time between the two clicks of a double-click
+	doubleClickInterval = 400 * time.Millisecond
+	// wheelLines is how far one step of the scroll wheel moves a note or the editor
+	wheelLines = 3
+	// boxInsetTop and boxInsetLeft cover the border and padding of a list box
+	boxInsetTop  = 2
+	boxInsetLeft = 3
+	// itemPaddingLeft is the padding before the text of a list row
+	itemPaddingLeft = 2
+	// textareaInsetTop and textareaInsetLeft cover the border and padding around the textarea
+	textareaInsetTop  = 1
+	textareaInsetLeft = 2
+)
+
+// click remembers the last click to detect double-clicks
+type click struct {
+	state string
+	row   int
+	at    time.Time
+}
+
+// updateMouse handles clicks and the scroll wheel on the current screen
+func (m Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if msg.Action != tea.MouseActionPress {
+		return m, nil
+	}
+
+	switch m.state {
+	case "list":
+		m.mouseList(msg)
+	case "view":
+		if !m.clickedTab(msg) {
+			m.mouseView(msg)
+		}
+	case "edit":
+		if !m.clickedTab(msg) {
+			m.mouseEditor(msg)
+		}
+	case "create":
+		m.mouseEditor(msg)
+	case "vaults":
+		m.mouseVaults(msg)
+	case "search":
+		m.mouseSearch(msg)
+	}
+	return m, nil
+}
+
+// screenTop returns the first screen row below the top margin and the tab bar
+func (m Model) screenTop() int {
+	if m.showsTabBar() {
+		return mainMarginTop + tabBarHeight
+	}
+	return mainMarginTop
+}
+
+// clickedTab switches to a tab clicked in the tab bar and reports whether one was
+func (m *Model) clickedTab(msg tea.MouseMsg) bool {
+	if !m.showsTabBar() || msg.Button != tea.MouseButtonLeft || msg.Y != mainMarginTop {
+		return false
+	}
+	if i, ok := m.tabAt(msg.X); ok {
+		m.showTab(i)
+	}
+	return true
+}
+
+// doubleClicked records a click on row and reports whether it completes a double-click
+func (m *Model) doubleClicked(row int) bool {
+	now := time.Now()
+	last := m.lastClick
+	if last.state == m.state && last.row == row && now.Sub(last.at) <= doubleClickInterval {
+		// A third click starts a new double-click
+		m.lastClick = click{}
+		return true
+	}
+	m.lastClick = click{state: m.state, row: row, at: now}
+	return false
+}
+
+// mouseList moves the cursor to a clicked note, opens it on a double-click
+// and filters by a clicked tag. Ctrl-click selects the note and shift-click
+// selects the notes up to it.
+func (m *Model) mouseList(msg tea.MouseMsg) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.moveCursor(-1)
+	case tea.MouseButtonWheelDown:
+		m.moveCursor(1)
+	case tea.MouseButtonLeft:
+		i, ok := m.listRowAt(msg.X, msg.Y)
+		if !ok {
+			return
+		}
+		m.cursor = i
+		m.ensureCursorVisible()
+
+		switch {
+		case msg.Ctrl:
+			m.toggleSelect(i)
+			return
+		case msg.Shift:
+			m.selectRange()
+			return
+		}
+		if tag := m.listTagAt(i, msg.X); tag != "" {
+			m.filterByTag(tag)
+			return
+		}
+		if m.doubleClicked(i) {
+			m.openNote()
+		}
+	}
+}
+
+// listRowAt returns the index of the note shown at a screen position
+func (m Model) listRowAt(x, y int) (int, bool) {
+	row := y - m.screenTop() - boxInsetTop
+	if row < 0 || row >= m.visibleRows() {
+		return 0, false
+	}
+	if m.splitLayout() {
+		if list, _ := m.paneWidths(); x >= mainMarginLeft+list {
+			return 0, false
+		}
+	}
+
+	i := m.offset + row
+	if i >= len(m.notes) {
+		return 0, false
+	}
+	return i, true
+}
+
+// listTagAt returns the tag shown at column x of a list row, if any
+func (m Model) listTagAt(i, x int) string {
+	n := m.notes[i]
+	col := x - mainMarginLeft - boxInsetLeft - itemPaddingLeft
+	// Tags cut off by the row width cannot be clicked
+	if m.width > 0 && col >= m.listItemWidth()-1 {
+		return ""
+	}
+	// Rows start with the cursor and selection marks, the title and the opening bracket
+	return tagAt(n.Metadata.Tags, col-ansi.StringWidth(m.listPrefix(i)+n.DisplayTitle()+" ["))
+}
+
+// tagAt returns the tag at col of tags rendered as "tag, tag", or "" between tags
+func tagAt(tags []string, col int) string {
+	start := 0
+	for _, tag := range tags {
+		end := start + ansi.StringWidth(tag)
+		if col >= start && col < end {
+			return tag
+		}
+		start = end + len(", ")
+	}
+	return ""
+}
+
+// mouseView scrolls the note and filters by a tag clicked in its header
+func (m *Model) mouseView(msg tea.MouseMsg) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.scrollView(-wheelLines)
+	case tea.MouseButtonWheelDown:
+		m.scrollView(wheelLines)
+	case tea.MouseButtonLeft:
+		if msg.Y != m.screenTop() || len(m.notes) == 0 {
+			return
+		}
+		n := m.notes[m.cursor]
+		// The tags follow the title after a space and the opening bracket
+		col := msg.X - mainMarginLeft - lipgloss.Width(noteTitle(n)) - len(" [")
+		if tag := tagAt(n.Metadata.Tags, col); tag != "" {
+			m.filterByTag(tag)
+		}
+	}
+}
+
+// mouseEditor moves the textarea cursor to a clicked position or by the scroll wheel
+func (m *Model) mouseEditor(msg tea.MouseMsg) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.moveEditorCursor(m.editorCursorRow() - wheelLines)
+	case tea.MouseButtonWheelDown:
+		m.moveEditorCursor(m.editorCursorRow() + wheelLines)
+	case tea.MouseButtonLeft:
+		top := m.screenTop() + strings.Count(m.editorHeader(), "\n") + textareaInsetTop
+		left := mainMarginLeft + textareaInsetLeft + lipgloss.Width(m.textarea.Prompt)
+		row := msg.Y - top
+		if row < 0 || row >= m.textarea.Height() {
+			return
+		}
+
+		m.moveEditorCursor(m.editorScroll + row)
+		m.setEditorColumn(msg.X - left)
+	default:
+		return
+	}
+
+	// Let the textarea scroll to the cursor
+	m.textarea, _ = m.textarea.Update(nil)
+	m.trackEditorScroll()
+}
+
+// editorCursorRow returns the row of the textarea cursor counting wrapped lines
+func (m Model) editorCursorRow() int {
+	return cursorRow(m.textarea)
+}
+
+// cursorRow counts the rows above the cursor of a textarea. The textarea
+// is a copy, so moving its cursor leaves the original alone.
+func cursorRow(ta textarea.Model) int {
+	row := 0
+	for ta.Line() > 0 || ta.LineInfo().RowOffset > 0 {
+		ta.CursorUp()
+		row++
+	}
+	return row
+}
+
+// moveEditorCursor moves the textarea cursor to a row counting wrapped lines
+func (m *Model) moveEditorCursor(target int) {
+	row := m.editorCursorRow()
+	for ; row > target && row > 0; row-- {
+		m.textarea.CursorUp()
+	}
+	for ; row < target; row++ {
+		line, offset := m.textarea.Line(), m.textarea.LineInfo().RowOffset
+		m.textarea.CursorDown()
+		if m.textarea.Line() == line && m.textarea.LineInfo().RowOffset == offset {
+			// Already on the last row
+			return
+		}
+	}
+}
+
+// setEditorColumn moves the textarea cursor to a display column of its current row
+func (m *Model) setEditorColumn(col int) {
+	lines := strings.Split(m.textarea.Value(), "\n")
+	line := []rune(lines[m.textarea.Line()])
+	info := m.textarea.LineInfo()
+
+	// A wrapped row ends where the next one starts
+	end := len(line)
+	if info.RowOffset+1 < info.Height {
+		end = min(end, info.StartColumn+info.CharWidth-1)
+	}
+
+	pos, width := info.StartColumn, 0
+	for pos < end {
+		w := ansi.StringWidth(string(line[pos]))
+		if width+w > col {
+			break
+		}
+		width += w
+		pos++
+	}
+	m.textarea.SetCursor(pos)
+}
+
+// trackEditorScroll follows the textarea as it scrolls to keep the cursor
+// in view, which it does not expose, so clicks can be mapped to rows
+func (m *Model) trackEditorScroll() {
+	row := m.editorCursorRow()
+	if row < m.editorScroll {
+		m.editorScroll = row
+	}
+	if height := m.textarea.Height(); row >= m.editorScroll+height {
+		m.editorScroll = row - height + 1
+	}
+}
+
+// mouseVaults selects a clicked vault and switches to it on a double-click
+func (m *Model) mouseVaults(msg tea.MouseMsg) {
+	names := m.config.VaultNames()
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.vaultCursor = max(0, m.vaultCursor-1)
+	case tea.MouseButtonWheelDown:
+		m.vaultCursor = max(0, min(len(names)-1, m.vaultCursor+1))
+	case tea.MouseButtonLeft:
+		i := msg.Y - m.screenTop() - strings.Count(m.vaultsHeader(), "\n") - boxInsetTop
+		if i < 0 || i >= len(names) {
+			return
+		}
+		m.vaultCursor = i
+		if m.doubleClicked(i) {
+			m.openVault()
+		}
+	}
+}
+
+// mouseSearch selects a clicked match and opens it on a double-click
+func (m *Model) mouseSearch(msg tea.MouseMsg) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.searchCursor = max(0, m.searchCursor-1)
+	case tea.MouseButtonWheelDown:
+		m.searchCursor = max(0, min(len(m.searchResults)-1, m.searchCursor+1))
+	case tea.MouseButtonLeft:
+		start, end := m.searchWindow()
+		i := start + msg.Y - m.screenTop() - strings.Count(m.searchHeader(), "\n") - boxInsetTop
+		if i < start || i >= end {
+			return
+		}
+		m.searchCursor = i
+		if m.doubleClicked(i) {
+			m.openSearchMatch()
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"notes-app/internal/app"
+	"notes-app/internal/common"
+	"notes-app/internal/storage"
+)
+
+// notifyLevel tells information apart from errors
+type notifyLevel int
+
+const (
+	levelInfo notifyLevel = iota
+	levelError
+)
+
+const (
+	// infoTimeout and errorTimeout are how long toasts stay on screen
+	infoTimeout  = 4 * time.Second
+	errorTimeout = 10 * time.Second
+	// maxToasts is how many toasts are shown at once, the oldest give way
+	maxToasts = 3
+	// maxNotifications is how many notifications the history keeps
+	maxNotifications = 100
+	// toastWidth is the widest a toast gets, including its border
+	toastWidth = 48
+)
+
+// notification is a message shown as a toast and kept in the history
+type notification struct {
+	id    int
+	level notifyLevel
+	text  string
+	hint  string // what can be done about an error
+	at    time.Time
+}
+
+// toastExpiredMsg hides the toast of a notification once its time is up
+type toastExpiredMsg struct {
+	id int
+}
+
+// info shows a message as a toast
+func (m *Model) info(format string, args ...any) {
+	m.notify(notification{level: levelInfo, text: fmt.Sprintf(format, args...)})
+}
+
+// fail shows an error as a toast with a hint on what to do about it
+func (m *Model) fail(err error) {
+	if err == nil {
+		return
+	}
+	log.Debug("showing error", "err", err)
+
+	text, hint := m.describeError(err)
+	m.notify(notification{level: levelError, text: text, hint: hint})
+
+	// The list still shows a note that is gone
+	if errors.Is(err, storage.ErrNotFound) {
+		if err := m.notesApp.RefreshIndex(); err != nil {
+			log.Warn("failed to reload notes", "err", err)
+		}
+		m.refreshNotes()
+		m.syncTabs()
+	}
+}
+
+// describeError turns an error into a message and a hint for its category
+func (m Model) describeError(err error) (string, string) {
+	// Errors joined from bulk actions are shown one per line
+	text := err.Error()
+
+	var nameErr *storage.NameError
+	switch {
+	case errors.As(err, &nameErr):
+		return fmt.Sprintf("'%s' cannot be used: %s", nameErr.Name, nameErr.Reason), "Choose another name"
+	case errors.Is(err, storage.ErrNotFound):
+		return text, "It was moved or deleted outside the app, the list has been reloaded"
+	case errors.Is(err, storage.ErrAlreadyExists):
+		return text, "Choose another name, or rename or move the other note first"
+	case errors.Is(err, storage.ErrPermission):
+		return text, fmt.Sprintf("Check that you may read and write %s", common.CollapseHome(m.notesApp.RootPath()))
+	case errors.Is(err, storage.ErrInvalidMetadata):
+		return text, fmt.Sprintf("Press '%s' on the note list to repair or quarantine broken notes",
+			m.keys.describe("list", actionProblems))
+	case errors.Is(err, app.ErrUnsupported):
+		return text, fmt.Sprintf("Press '%s' on the note list to switch to a filesystem vault", m.keys.describe("list", actionVaults))
+	}
+	return text, ""
+}
+
+// notify adds a notification to the history and shows it as a toast
+func (m *Model) notify(n notification) {
+	m.lastNotification++
+	n.id = m.lastNotification
+	n.at = time.Now()
+
+	m.notifications = append(m.notifications, n)
+	if len(m.notifications) > maxNotifications {
+		m.notifications = m.notifications[len(m.notifications)-maxNotifications:]
+	}
+	if n.level == levelError {
+		m.unread++
+	}
+
+	toasts := append([]notification{}, m.toasts...)
+	m.toasts = append(toasts, n)
+	if len(m.toasts) > maxToasts {
+		m.toasts = m.toasts[len(m.toasts)-maxToasts:]
+	}
+}
+
+// expireToasts hides the toasts of notifications newer than since once their time is up
+func (m Model) expireToasts(since int) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, n := range m.toasts {
+		if n.id <= since {
+			continue
+		}
+		id, timeout := n.id, infoTimeout
+		if n.level == levelError {
+			timeout = errorTimeout
+		}
+		cmds = append(cmds, tea.Tick(timeout, func(time.Time) tea.Msg {
+			return toastExpiredMsg{id: id}
+		}))
+	}
+	return tea.Batch(cmds...)
+}
+
+// hideToast hides the toast of a notification, which stays in the history
+func (m *Model) hideToast(id int) {
+	toasts := make([]notification, 0, len(m.toasts))
+	for _, n := range m.toasts {
+		if n.id != id {
+			toasts = append(toasts, n)
+		}
+	}
+	m.toasts = toasts
+}
+
+// dismissToast hides the newest toast
+func (m *Model) dismissToast() {
+	if len(m.toasts) > 0 {
+		m.hideToast(m.toasts[len(m.toasts)-1].id)
+	}
+}
+
+// openNotifications shows the notification history, newest first
+func (m *Model) openNotifications() {
+	m.state = "notifications"
+	m.notificationCursor = 0
+	m.unread = 0
+}
+
+// updateNotifications handles keys on the notification history
+func (m Model) updateNotifications(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case m.matches(msg, actionBack):
+		m.state = "list"
+	case m.matches(msg, actionUp):
+		if m.notificationCursor > 0 {
+			m.notificationCursor--
+		}
+	case m.matches(msg, actionDown):
+		if m.notificationCursor < len(m.notifications)-1 {
+			m.notificationCursor++
+		}
+	case m.matches(msg, actionClearNotifications):
+		m.notifications = nil
+		m.toasts = nil
+		m.notificationCursor = 0
+	}
+	return m, nil
+}
+
+// notificationWindow returns the range of the history shown, counted from the newest
+func (m Model) notificationWindow() (start, end int) {
+	// Every notification takes up to two rows
+	rows := max(1, m.visibleRows()/2)
+	if m.notificationCursor >= rows {
+		start = m.notificationCursor - rows + 1
+	}
+	return start, min(start+rows, len(m.notifications))
+}
+
+// renderNotifications lists past notifications, newest first
+func (m Model) renderNotifications() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Notifications") + "\n\n")
+
+	if len(m.notifications) == 0 {
+		s.WriteString("Nothing has happened yet.\n")
+		return s.String()
+	}
+
+	start, end := m.notificationWindow()
+	var list strings.Builder
+	for i := start; i < end; i++ {
+		n := m.notifications[len(m.notifications)-1-i]
+		cursor := " "
+		if i == m.notificationCursor {
+			cursor = ">"
+		}
+
+		line := fmt.Sprintf("%s %s %s %s", cursor, n.at.Format("15:04:05"), levelIcon(n.level),
+			strings.ReplaceAll(n.text, "\n", "; "))
+		if n.hint != "" {
+			line += "\n    " + n.hint
+		}
+		if m.width > 0 {
+			line = truncateLines(line, m.width-listItemChromeWidth)
+		}
+
+		if i == m.notificationCursor {
+			list.WriteString(selectedNoteStyle.Render(line))
+		} else {
+			list.WriteString(noteStyle.Render(line))
+		}
+		list.WriteString("\n")
+	}
+	s.WriteString(listStyle.Render(strings.TrimSuffix(list.String(), "\n")))
+	return s.String()
+}
+
+// levelIcon marks notifications by level
+func levelIcon(level notifyLevel) string {
+	if level == levelError {
+		return "✗"
+	}
+	return "✓"
+}
+
+// truncateLines cuts every line of s to width cells
+func truncateLines(s string, width int) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, "…")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderToasts stacks the visible toasts, newest at the bottom
+func (m Model) renderToasts() string {
+	width := toastWidth
+	if m.width > 0 {
+		width = min(width, m.width-mainMarginWidth)
+	}
+
+	var toasts []string
+	for _, n := range m.toasts {
+		style, text := infoToastStyle, levelIcon(n.level)+" "+n.text
+		if n.level == levelError {
+			style = errorToastStyle
+		}
+		if n.hint != "" {
+			text += "\n" + helpStyle.Render(n.hint)
+		}
+		toasts = append(toasts, style.Width(width-2).Render(text))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, toasts...)
+}
+
+// overlayToasts draws the visible toasts over the top right corner of the screen
+func (m Model) overlayToasts(screen string) string {
+	if len(m.toasts) == 0 {
+		return screen
+	}
+	toasts := m.renderToasts()
+	x := max(0, max(m.width, StandardWidth)-lipgloss.Width(toasts)-mainMarginLeft)
+	return overlay(screen, toasts, x, mainMarginTop)
+}
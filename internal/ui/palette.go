@@ -0,0 +1,273 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// paletteWidth is the widest the command palette gets, including its border
+	paletteWidth = 60
+	// paletteRows is the most commands shown at once
+	paletteRows = 10
+	// paletteTop is the screen row the palette is drawn at
+	paletteTop = 2
+)
+
+// paletteHidden are actions left out of the palette because they only move the cursor
+var paletteHidden = map[string]bool{
+	actionUp: true, actionDown: true, actionPageUp: true, actionPageDown: true,
+	actionTop: true, actionBottom: true, actionPalette: true,
+}
+
+// paletteItem is a command offered by the palette
+type paletteItem struct {
+	action string
+	title  string
+	keys   string
+	score  int
+}
+
+// newPaletteInput returns the input the palette is filtered with
+func newPaletteInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "Type a command..."
+	ti.Prompt = "> "
+	return ti
+}
+
+// openPalette shows the command palette for the current screen
+func (m *Model) openPalette() {
+	m.paletteScope = m.keyScope()
+	m.state = "palette"
+	m.paletteInput.Reset()
+	m.paletteInput.Focus()
+	m.filterPalette()
+}
+
+// filterPalette lists the commands of the screen the palette was opened
+// from that fuzzily match the query, best matches first
+func (m *Model) filterPalette() {
+	query := strings.TrimSpace(m.paletteInput.Value())
+	scope := m.keys[m.paletteScope]
+
+	actions := append([]string{}, scope.actions...)
+	for _, action := range globalActions {
+		if !contains(actions, action) {
+			actions = append(actions, action)
+		}
+	}
+
+	m.paletteItems = m.paletteItems[:0]
+	for _, action := range actions {
+		if paletteHidden[action] {
+			continue
+		}
+		help := scope.bindings[action].Help()
+		item := paletteItem{action: action, title: help.Desc, keys: help.Key}
+
+		if query != "" {
+			titleScore, titleOK := fuzzyScore(query, item.title)
+			nameScore, nameOK := fuzzyScore(query, strings.ReplaceAll(action, "_", " "))
+			if !titleOK && !nameOK {
+				continue
+			}
+			item.score = max(titleScore, nameScore)
+		}
+		m.paletteItems = append(m.paletteItems, item)
+	}
+
+	sort.SliceStable(m.paletteItems, func(i, j int) bool {
+		return m.paletteItems[i].score > m.paletteItems[j].score
+	})
+	m.paletteCursor = 0
+}
+
+// fuzzyScore reports whether the runes of query appear in text in order,
+// ignoring case and spaces, and scores the match. Runes that follow each
+// other or start a word score higher.
+func fuzzyScore(query, text string) (int, bool) {
+	q := []rune(strings.ToLower(strings.Join(strings.Fields(query), "")))
+	t := []rune(strings.ToLower(text))
+
+	score, qi, prev := 0, 0, -2
+	for i := 0; i < len(t) && qi < len(q); i++ {
+		if t[i] != q[qi] {
+			continue
+		}
+		score++
+		if i == prev+1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(t[i-1]) && !unicode.IsDigit(t[i-1]) {
+			score += 3
+		}
+		prev = i
+		qi++
+	}
+	return score, qi == len(q)
+}
+
+// updatePalette handles keys while the command palette is open
+func (m Model) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case m.matches(msg, actionBack):
+		m.closePalette()
+		return m, nil
+	case m.matches(msg, actionUp):
+		if m.paletteCursor > 0 {
+			m.paletteCursor--
+		}
+		return m, nil
+	case m.matches(msg, actionDown):
+		if m.paletteCursor < len(m.paletteItems)-1 {
+			m.paletteCursor++
+		}
+		return m, nil
+	case m.matches(msg, actionSubmit):
+		if len(m.paletteItems) == 0 {
+			return m, nil
+		}
+		return m.runCommand(m.paletteItems[m.paletteCursor].action)
+	}
+
+	var cmd tea.Cmd
+	m.paletteInput, cmd = m.paletteInput.Update(msg)
+	m.filterPalette()
+	return m, cmd
+}
+
+// closePalette returns to the screen the palette was opened from
+func (m *Model) closePalette() {
+	m.state = m.paletteScope
+	m.paletteInput.Blur()
+}
+
+// runCommand runs an action on the screen the palette was opened from by
+// replaying its first key there, so it behaves exactly like pressing the key
+func (m Model) runCommand(action string) (tea.Model, tea.Cmd) {
+	scope := m.paletteScope
+	m.closePalette()
+
+	keys := m.keys[scope].bindings[action].Keys()
+	if len(keys) == 0 {
+		return m, nil
+	}
+	msg, ok := keyMsg(keys[0])
+	if !ok {
+		m.fail(fmt.Errorf("the palette cannot run %s, press '%s' instead", action, m.keys.describe(scope, action)))
+		return m, nil
+	}
+	log.Debug("running command from palette", "action", action, "key", keys[0])
+	// Update, which called this, already wraps the result
+	return m.update(msg)
+}
+
+// keyTypes maps the names of special keys to their types
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	// Special keys have negative types, control keys the ASCII codes
+	for t := tea.KeyType(-100); t <= 127; t++ {
+		if name := (tea.Key{Type: t}).String(); name != "" && t != tea.KeyRunes {
+			if _, taken := types[name]; !taken {
+				types[name] = t
+			}
+		}
+	}
+	return types
+}()
+
+// keyMsg builds the message a key press with the given name produces
+func keyMsg(name string) (tea.KeyMsg, bool) {
+	if t, ok := keyTypes[name]; ok {
+		k := tea.Key{Type: t}
+		if t == tea.KeySpace {
+			k.Runes = []rune{' '}
+		}
+		return tea.KeyMsg(k), true
+	}
+
+	alt := false
+	if rest, ok := strings.CutPrefix(name, "alt+"); ok && rest != "" {
+		if msg, ok := keyMsg(rest); ok {
+			msg.Alt = true
+			return msg, true
+		}
+		alt, name = true, rest
+	}
+	if runes := []rune(name); len(runes) == 1 {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: runes, Alt: alt}, true
+	}
+	return tea.KeyMsg{}, false
+}
+
+// renderPalette draws the command palette over the screen it was opened from
+func (m Model) renderPalette() string {
+	under := m
+	under.closePalette()
+	screen := under.renderScreen()
+
+	width := paletteWidth
+	if m.width > 0 {
+		width = min(width, m.width-mainMarginWidth)
+	}
+	inner := width - boxChromeWidth
+
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Commands: "+m.keys[m.paletteScope].title) + "\n")
+	m.paletteInput.Width = inner - lipgloss.Width(m.paletteInput.Prompt) - 1
+	s.WriteString(m.paletteInput.View() + "\n\n")
+
+	if len(m.paletteItems) == 0 {
+		s.WriteString(helpStyle.Render("No matching commands"))
+	}
+	start := max(0, m.paletteCursor-paletteRows+1)
+	end := min(start+paletteRows, len(m.paletteItems))
+	for i := start; i < end; i++ {
+		item := m.paletteItems[i]
+		keys := helpStyle.Render(item.keys)
+		title := ansi.Truncate(item.title, inner-lipgloss.Width(keys)-3, "…")
+		gap := max(1, inner-2-lipgloss.Width(title)-lipgloss.Width(keys))
+		line := title + strings.Repeat(" ", gap) + keys
+
+		if i == m.paletteCursor {
+			s.WriteString(selectedNoteStyle.Padding(0, 1).Render(line))
+		} else {
+			s.WriteString(noteStyle.Padding(0, 1).Render(line))
+		}
+		if i < end-1 {
+			s.WriteString("\n")
+		}
+	}
+	s.WriteString("\n\n" + m.renderShortHelp())
+
+	box := paletteStyle.Width(width - 2).Render(s.String())
+	x := (max(m.width, StandardWidth) - width) / 2
+	return overlay(screen, box, x, paletteTop)
+}
+
+// overlay draws fg on top of bg with its top left corner at column x and row y
+func overlay(bg, fg string, x, y int) string {
+	lines := strings.Split(bg, "\n")
+	for i, line := range strings.Split(fg, "\n") {
+		row := y + i
+		for row >= len(lines) {
+			lines = append(lines, "")
+		}
+
+		left := ansi.Truncate(lines[row], x, "")
+		if w := ansi.StringWidth(left); w < x {
+			left += strings.Repeat(" ", x-w)
+		}
+		right := ansi.TruncateLeft(lines[row], x+ansi.StringWidth(line), "")
+		lines[row] = left + ansi.ResetStyle + line + ansi.ResetStyle + right
+	}
+	return strings.Join(lines, "\n")
+}
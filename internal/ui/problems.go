@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// updateProblems handles keys on the problems screen
+func (m Model) updateProblems(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	problems := m.notesApp.Problems()
+
+	switch {
+	case m.matches(msg, actionBack):
+		m.state = "list"
+	case m.matches(msg, actionUp):
+		if m.problemCursor > 0 {
+			m.problemCursor--
+		}
+	case m.matches(msg, actionDown):
+		if m.problemCursor < len(problems)-1 {
+			m.problemCursor++
+		}
+	case m.matches(msg, actionOpen), m.matches(msg, actionExternalEditor):
+		if len(problems) > 0 {
+			file := problems[m.problemCursor].File
+			return m, tea.ExecProcess(m.editorCommand(file), func(err error) tea.Msg {
+				return editorFinishedMsg{err: err}
+			})
+		}
+	case m.matches(msg, actionRepair):
+		if len(problems) > 0 {
+			m.applyProblemAction(m.notesApp.RepairProblem(problems[m.problemCursor]))
+		}
+	case m.matches(msg, actionQuarantine):
+		if len(problems) > 0 {
+			m.applyProblemAction(m.notesApp.QuarantineProblem(problems[m.problemCursor]))
+		}
+	}
+
+	return m, nil
+}
+
+// applyProblemAction refreshes the screen after a repair or quarantine
+func (m *Model) applyProblemAction(err error) {
+	if err != nil {
+		m.fail(err)
+		return
+	}
+
+	m.refreshNotes()
+	if remaining := len(m.notesApp.Problems()); m.problemCursor >= remaining {
+		m.problemCursor = remaining - 1
+	}
+	if m.problemCursor < 0 {
+		m.problemCursor = 0
+	}
+}
+
+// problemWindow returns the range of problems shown, which keeps the cursor in view
+func (m Model) problemWindow(count int) (start, end int) {
+	// Every problem takes two rows
+	rows := max(1, m.visibleRows()/2)
+	if m.problemCursor >= rows {
+		start = m.problemCursor - rows + 1
+	}
+	return start, min(start+rows, count)
+}
+
+// renderProblems lists every note that failed to load
+func (m Model) renderProblems() string {
+	var s strings.Builder
+	problems := m.notesApp.Problems()
+
+	s.WriteString(titleStyle.Render("Problems") + "\n\n")
+
+	if len(problems) == 0 {
+		s.WriteString("All notes loaded without problems.\n")
+		return s.String()
+	}
+
+	start, end := m.problemWindow(len(problems))
+	var list strings.Builder
+	for i := start; i < end; i++ {
+		p := problems[i]
+		cursor := " "
+		if i == m.problemCursor {
+			cursor = ">"
+		}
+
+		line := fmt.Sprintf("%s %s (%s)\n    %s", cursor, p.File, p.Kind, strings.ReplaceAll(p.Err.Error(), "\n", "; "))
+		if m.width > 0 {
+			line = truncateLines(line, m.width-listItemChromeWidth)
+		}
+		if i == m.problemCursor {
+			list.WriteString(selectedNoteStyle.Render(line))
+		} else {
+			list.WriteString(noteStyle.Render(line))
+		}
+		list.WriteString("\n")
+	}
+	s.WriteString(listStyle.Render(strings.TrimSuffix(list.String(), "\n")))
+
+	return s.String()
+}
@@ -0,0 +1,348 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"notes-app/internal/note"
+)
+
+// maxListedTargets is how many note names a bulk action lists before summarising
+const maxListedTargets = 8
+
+// isSelected reports whether the note at index i is selected
+func (m Model) isSelected(i int) bool {
+	_, ok := m.selected[i]
+	return ok
+}
+
+// toggleSelect selects or unselects the note at index i and makes it the
+// start of the next range
+func (m *Model) toggleSelect(i int) {
+	if i < 0 || i >= len(m.notes) {
+		return
+	}
+	if m.isSelected(i) {
+		delete(m.selected, i)
+	} else {
+		m.selected[i] = struct{}{}
+	}
+	m.selectAnchor = i
+}
+
+// selectRange selects every note between the last toggled note and the cursor
+func (m *Model) selectRange() {
+	if len(m.notes) == 0 {
+		return
+	}
+	anchor := m.selectAnchor
+	if anchor < 0 || anchor >= len(m.notes) {
+		anchor = m.cursor
+	}
+	for i := min(anchor, m.cursor); i <= max(anchor, m.cursor); i++ {
+		m.selected[i] = struct{}{}
+	}
+	m.selectAnchor = m.cursor
+}
+
+// selectAll selects every listed note
+func (m *Model) selectAll() {
+	for i := range m.notes {
+		m.selected[i] = struct{}{}
+	}
+}
+
+// clearSelection unselects every note
+func (m *Model) clearSelection() {
+	m.selected = make(map[int]struct{})
+	m.selectAnchor = -1
+}
+
+// invertSelection selects the notes that are not selected and unselects the others
+func (m *Model) invertSelection() {
+	inverted := make(map[int]struct{}, len(m.notes)-len(m.selected))
+	for i := range m.notes {
+		if !m.isSelected(i) {
+			inverted[i] = struct{}{}
+		}
+	}
+	m.selected = inverted
+}
+
+// selectedPaths returns the paths of the selected notes and of the note
+// ranges start from, so the selection can follow the notes when the list
+// is reloaded
+func (m Model) selectedPaths() (map[string]bool, string) {
+	paths := make(map[string]bool, len(m.selected))
+	for i := range m.selected {
+		if i < len(m.notes) {
+			paths[m.notes[i].Path] = true
+		}
+	}
+	anchor := ""
+	if m.selectAnchor >= 0 && m.selectAnchor < len(m.notes) {
+		anchor = m.notes[m.selectAnchor].Path
+	}
+	return paths, anchor
+}
+
+// restoreSelection selects the notes with the given paths in the reloaded list
+func (m *Model) restoreSelection(paths map[string]bool, anchor string) {
+	m.clearSelection()
+	for i, n := range m.notes {
+		if paths[n.Path] {
+			m.selected[i] = struct{}{}
+		}
+		if n.Path == anchor {
+			m.selectAnchor = i
+		}
+	}
+}
+
+// startBulk makes the next action apply to the selected notes, if any
+func (m *Model) startBulk() {
+	m.bulk = len(m.selected) > 0
+}
+
+// targetNotes returns the notes a bulk action applies to: the selected
+// notes in list order, or the note under the cursor otherwise
+func (m Model) targetNotes() []*note.Note {
+	if !m.bulk {
+		if len(m.notes) == 0 {
+			return nil
+		}
+		return []*note.Note{m.notes[m.cursor]}
+	}
+
+	var notes []*note.Note
+	for i, n := range m.notes {
+		if m.isSelected(i) {
+			notes = append(notes, n)
+		}
+	}
+	return notes
+}
+
+// targetPaths returns the paths of the notes a bulk action applies to
+func (m Model) targetPaths() []string {
+	notes := m.targetNotes()
+	paths := make([]string, len(notes))
+	for i, n := range notes {
+		paths[i] = n.Path
+	}
+	return paths
+}
+
+// targetTags returns the tags of the notes a bulk action applies to, each once
+func (m Model) targetTags() []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, n := range m.targetNotes() {
+		for _, tag := range n.Metadata.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// describeTargets names the notes a bulk action applies to for screen titles
+func (m Model) describeTargets() string {
+	notes := m.targetNotes()
+	if len(notes) == 1 {
+		return notes[0].Name
+	}
+	return fmt.Sprintf("%d notes", len(notes))
+}
+
+// renderTargets lists the names of the notes a bulk action applies to
+func (m Model) renderTargets() string {
+	notes := m.targetNotes()
+
+	var s strings.Builder
+	for i, n := range notes {
+		if i == maxListedTargets {
+			s.WriteString(helpStyle.Render(fmt.Sprintf("  and %d more", len(notes)-i)) + "\n")
+			break
+		}
+		s.WriteString("  " + n.Name + "\n")
+	}
+	return s.String()
+}
+
+// finishBulk reloads the list after a bulk action and returns to it. The
+// selection is dropped unless some notes failed, so they can be retried.
+func (m *Model) finishBulk(err error) {
+	if err != nil {
+		m.fail(err)
+	} else if m.bulk {
+		m.clearSelection()
+	}
+	m.bulk = false
+	m.refreshNotes()
+	m.syncTabs()
+	m.state = "list"
+}
+
+// newFolderInput returns the input folders are entered in when moving and
+// exporting notes
+func newFolderInput() textinput.Model {
+	ti := textinput.New()
+	ti.Width = StandardWidth - StandardTextInputPadding
+	return ti
+}
+
+// startFolderInput asks for a folder for the move and export screens
+func (m *Model) startFolderInput(state, placeholder string) {
+	m.startBulk()
+	m.state = state
+	m.folderInput.Reset()
+	m.folderInput.Placeholder = placeholder
+	m.folderInput.Focus()
+}
+
+// startMerge asks to confirm merging the selected notes
+func (m *Model) startMerge() {
+	m.startBulk()
+	if len(m.targetNotes()) < 2 {
+		m.fail(fmt.Errorf("select at least two notes to merge with '%s'", m.describe(actionToggleSelect)))
+		return
+	}
+	m.state = "confirm_merge"
+}
+
+// deleteTargets deletes the notes a bulk action applies to
+func (m *Model) deleteTargets() {
+	if !m.bulk {
+		n := m.notes[m.cursor]
+		if err := m.notesApp.DeleteNote(n.Path); err != nil {
+			m.fail(err)
+			return
+		}
+		m.info("Deleted '%s'", n.Name)
+		m.refreshNotes()
+		m.syncTabs()
+		m.state = "list"
+		return
+	}
+
+	deleted, err := m.notesApp.DeleteNotes(m.targetPaths())
+	if deleted > 0 {
+		m.info("Deleted %s", countNotes(deleted))
+	}
+	m.finishBulk(err)
+}
+
+// countNotes describes a number of notes for status messages
+func countNotes(count int) string {
+	return fmt.Sprintf("%d %s", count, plural(count, "note", "notes"))
+}
+
+// updateMove handles keys while a folder to move notes to is entered
+func (m Model) updateMove(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case m.matches(msg, actionBack):
+		m.state = "list"
+		m.folderInput.Blur()
+		return m, nil
+	case m.matches(msg, actionSubmit):
+		folder := strings.Trim(strings.TrimSpace(m.folderInput.Value()), "/")
+		moved, err := m.notesApp.MoveNotes(m.targetPaths(), folder)
+		if moved > 0 && folder == "" {
+			m.info("Moved %s to the top of the vault", countNotes(moved))
+		} else if moved > 0 {
+			m.info("Moved %s to %s", countNotes(moved), folder)
+		}
+		m.finishBulk(err)
+		m.folderInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.folderInput, cmd = m.folderInput.Update(msg)
+	return m, cmd
+}
+
+// updateExport handles keys while a folder to export notes to is entered
+func (m Model) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case m.matches(msg, actionBack):
+		m.state = "list"
+		m.folderInput.Blur()
+		return m, nil
+	case m.matches(msg, actionSubmit):
+		if strings.TrimSpace(m.folderInput.Value()) == "" {
+			return m, nil
+		}
+		exported, err := m.notesApp.ExportNotes(m.targetPaths(), m.folderInput.Value())
+		if exported > 0 {
+			m.info("Exported %s to %s", countNotes(exported), strings.TrimSpace(m.folderInput.Value()))
+		}
+		m.finishBulk(err)
+		m.folderInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.folderInput, cmd = m.folderInput.Update(msg)
+	return m, cmd
+}
+
+// updateMerge handles keys while merging notes is confirmed
+func (m Model) updateMerge(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case m.matches(msg, actionBack):
+		m.state = "list"
+	case m.matches(msg, actionSubmit):
+		paths := m.targetPaths()
+		merged, err := m.notesApp.MergeNotes(paths)
+		if merged == nil {
+			m.fail(err)
+			m.state = "list"
+			return m, nil
+		}
+		m.info("Merged %s into '%s'", countNotes(len(paths)), merged.Name)
+		m.finishBulk(err)
+		m.selectByPath(merged.Path)
+	}
+	return m, nil
+}
+
+// renderMove renders the screen asking where to move notes
+func (m Model) renderMove() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Move: "+m.describeTargets()) + "\n\n")
+	s.WriteString("Enter a folder in the vault, or nothing for its top level:\n")
+	s.WriteString(inputStyle.Render(m.folderInput.View()) + "\n")
+	return s.String()
+}
+
+// renderExport renders the screen asking where to export notes
+func (m Model) renderExport() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Export: "+m.describeTargets()) + "\n\n")
+	s.WriteString("Enter the folder to copy the notes to:\n")
+	s.WriteString(inputStyle.Render(m.folderInput.View()) + "\n")
+	return s.String()
+}
+
+// renderMerge renders the screen confirming a merge
+func (m Model) renderMerge() string {
+	notes := m.targetNotes()
+
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Confirm Merge") + "\n\n")
+	s.WriteString(fmt.Sprintf("Append these notes to '%s' and delete them?\n\n", notes[0].Name))
+	for i, n := range notes[1:] {
+		if i == maxListedTargets {
+			s.WriteString(helpStyle.Render(fmt.Sprintf("  and %d more", len(notes)-1-i)) + "\n")
+			break
+		}
+		s.WriteString("  " + n.Name + "\n")
+	}
+	return s.String()
+}
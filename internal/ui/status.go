@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"notes-app/internal/common"
+	"notes-app/internal/note"
+)
+
+// renderStatusBar renders the bar at the bottom of the screen with the
+// current screen, vault and note, and errors not yet seen in the history
+func (m Model) renderStatusBar() string {
+	width := StandardWidth
+	if m.width > 0 {
+		width = m.width
+	}
+
+	mode := statusModeStyle.Render(m.keys[m.keyScope()].title)
+	left, leftStyle := common.CollapseHome(m.notesApp.RootPath()), statusBarStyle
+	if m.unread > 0 {
+		left = fmt.Sprintf("%d new %s, press '%s' · %s", m.unread, plural(m.unread, "error", "errors"),
+			m.keys.describe("list", actionNotifications), left)
+		leftStyle = statusMessageStyle
+	}
+
+	var info []string
+	if m.tagFilter != "" {
+		info = append(info, fmt.Sprintf("%d of %d notes", len(m.notes), m.totalNotes))
+	} else {
+		info = append(info, fmt.Sprintf("%d %s", m.totalNotes, plural(m.totalNotes, "note", "notes")))
+	}
+	info = append(info, m.noteStatus()...)
+	right := strings.Join(info, " · ")
+
+	// Each side keeps at least half of the bar when space runs out
+	inner := width - lipgloss.Width(mode) - 2
+	left = ansi.Truncate(left, max(inner/2, inner-ansi.StringWidth(right)-1), "…")
+	right = ansi.Truncate(right, max(0, inner-ansi.StringWidth(left)-1), "…")
+	gap := max(0, inner-ansi.StringWidth(left)-ansi.StringWidth(right))
+
+	return mode +
+		leftStyle.Render(" "+left) +
+		statusBarStyle.Render(strings.Repeat(" ", gap)+right+" ")
+}
+
+// noteStatus describes the note under the cursor on screens that show one
+func (m Model) noteStatus() []string {
+	switch m.state {
+	case "list", "view", "edit", "tags", "rename":
+	default:
+		return nil
+	}
+	if m.cursor < 0 || m.cursor >= len(m.notes) {
+		return nil
+	}
+
+	n := m.notes[m.cursor]
+	status := []string{"Modified " + n.ModTime.Format(m.config.DateTimeFormat)}
+	if m.words.counts(n) {
+		words := m.words.words
+		status = append(status, fmt.Sprintf("%d %s", words, plural(words, "word", "words")))
+	}
+	if len(n.Metadata.Tags) > 0 {
+		status = append(status, "#"+strings.Join(n.Metadata.Tags, " #"))
+	}
+	return status
+}
+
+// wordCount is the number of words of a note as it was when counted, so
+// the status bar does not load the note each time it is drawn
+type wordCount struct {
+	path    string
+	modTime time.Time
+	size    int64
+	words   int
+}
+
+// counts reports whether the count is of the note as it is now
+func (w wordCount) counts(n *note.Note) bool {
+	return w.path == n.Path && w.modTime.Equal(n.ModTime) && w.size == n.Size
+}
+
+// updateWordCount counts the words of the note under the cursor if it is
+// another note than last time or has changed since
+func (m *Model) updateWordCount() {
+	if m.cursor < 0 || m.cursor >= len(m.notes) {
+		return
+	}
+	n := m.notes[m.cursor]
+	if m.words.counts(n) {
+		return
+	}
+
+	content, err := m.notesApp.GetContent(n)
+	if err != nil {
+		m.words = wordCount{}
+		return
+	}
+	m.words = wordCount{path: n.Path, modTime: n.ModTime, size: n.Size, words: len(strings.Fields(content))}
+}
+
+// placeStatusBar puts the status bar on the last row of the terminal below the screen
+func (m Model) placeStatusBar(screen string) string {
+	bar := m.renderStatusBar()
+	if m.height <= 0 {
+		return screen + "\n" + bar
+	}
+	rows := m.height - 1 - lipgloss.Height(screen)
+	return screen + strings.Repeat("\n", max(0, rows)+1) + bar
+}
@@ -2,71 +2,142 @@ package ui
 
 import "github.com/charmbracelet/lipgloss"
 
+// Styles are rebuilt from the current theme by applyTheme
 var (
-	// Main styles
+	mainStyle         lipgloss.Style
+	titleStyle        lipgloss.Style
+	listStyle         lipgloss.Style
+	noteStyle         lipgloss.Style
+	selectedNoteStyle lipgloss.Style
+	previewStyle      lipgloss.Style
+	paletteStyle      lipgloss.Style
+	previewTitleStyle lipgloss.Style
+	inputStyle        lipgloss.Style
+	textareaStyle     lipgloss.Style
+	helpStyle         lipgloss.Style
+	errorStyle        lipgloss.Style
+	tagStyle          lipgloss.Style
+
+	statusBarStyle     lipgloss.Style
+	statusModeStyle    lipgloss.Style
+	statusMessageStyle lipgloss.Style
+	infoToastStyle     lipgloss.Style
+	errorToastStyle    lipgloss.Style
+	tabStyle           lipgloss.Style
+	activeTabStyle     lipgloss.Style
+
+	markdownHeadingStyle lipgloss.Style
+	markdownCodeStyle    lipgloss.Style
+	markdownLinkStyle    lipgloss.Style
+)
+
+func init() {
+	applyTheme(builtinThemes["dark"])
+}
+
+// applyTheme rebuilds every style from the theme's colors
+func applyTheme(t Theme) {
 	mainStyle = lipgloss.NewStyle().
-			Margin(1, 2)
+		Margin(1, 2)
 
-	// Title styles
 	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Background(lipgloss.Color("#7B2CBF")).
-			Padding(0, 1).
-			MarginBottom(1)
+		Bold(true).
+		Foreground(color(t.Title)).
+		Background(color(t.TitleBackground)).
+		Padding(0, 1).
+		MarginBottom(1)
 
-	// List styles
 	listStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#666666")).
-			Padding(1, 2)
-		// Width(StandardWidth)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color(t.Border)).
+		Padding(1, 2)
 
 	noteStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFFFF")).
-			Padding(0, 2)
+		Foreground(color(t.Text)).
+		Padding(0, 2)
 
 	selectedNoteStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#000000")).
-				Background(lipgloss.Color("#9D4EDD")).
-				Padding(0, 2)
+		Foreground(color(t.Selected)).
+		Background(color(t.SelectedBackground)).
+		Padding(0, 2)
+	if t.SelectedBackground == "" {
+		selectedNoteStyle = selectedNoteStyle.Reverse(true)
+	}
 
-	// Preview styles
 	previewStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#666666")).
-			Padding(1, 2).
-		// Width(StandardWidth).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color(t.Border)).
+		Padding(1, 2).
 		Height(6)
 
+	paletteStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color(t.Title)).
+		Padding(1, 2)
+
 	previewTitleStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#666666")).
-				Bold(true).
-				MarginTop(1)
+		Foreground(color(t.Muted)).
+		Bold(true).
+		MarginTop(1)
 
-	// Input styles
 	inputStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#666666")).
-			Padding(0, 1)
-		// Width(StandardWidth)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color(t.Border)).
+		Padding(0, 1)
 
 	textareaStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#666666")).
-			Padding(0, 1).
-			Width(StandardWidth).
-		// Width( - StandardTextInputPadding).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color(t.Border)).
+		Padding(0, 1).
+		Width(StandardWidth).
 		Height(StandardHeight)
 
-	// Utility styles
 	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#666666"))
+		Foreground(color(t.Muted))
 
 	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FF0000"))
+		Foreground(color(t.Error))
 
 	tagStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#5af78e")).
-			Italic(true)
-)
+		Foreground(color(t.Tag)).
+		Italic(true)
+
+	statusBarStyle = lipgloss.NewStyle().
+		Foreground(color(t.Text)).
+		Background(color(t.Border))
+
+	statusModeStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(color(t.Title)).
+		Background(color(t.TitleBackground)).
+		Padding(0, 1)
+
+	statusMessageStyle = statusBarStyle.
+		Bold(true)
+
+	infoToastStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color(t.Tag)).
+		Foreground(color(t.Text)).
+		Padding(0, 1)
+
+	errorToastStyle = infoToastStyle.
+		BorderForeground(color(t.Error)).
+		Foreground(color(t.Error))
+
+	tabStyle = lipgloss.NewStyle().
+		Foreground(color(t.Muted)).
+		Padding(0, 1)
+
+	activeTabStyle = statusModeStyle
+
+	markdownHeadingStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(color(t.TitleBackground))
+
+	markdownCodeStyle = lipgloss.NewStyle().
+		Foreground(color(t.Tag))
+
+	markdownLinkStyle = lipgloss.NewStyle().
+		Underline(true)
+}
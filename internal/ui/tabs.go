@@ -0,0 +1,352 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"notes-app/internal/storage"
+)
+
+const (
+	// tabBarHeight covers the tab bar and the blank line below it
+	tabBarHeight = 2
+	// tabGap is the space between two tabs in the tab bar
+	tabGap = 1
+	// editorHeight is the number of rows of the textarea
+	editorHeight = 18
+)
+
+// tab is a note open in the view or edit screen. Every tab keeps its own
+// scroll position and, while editing, its own unsaved text.
+type tab struct {
+	path         string
+	name         string
+	mode         string // "view" or "edit"
+	viewOffset   int
+	editor       textarea.Model
+	editorScroll int
+	saved        string // content of the note when editing started
+}
+
+// dirty reports whether the tab has edits that are not saved
+func (t tab) dirty() bool {
+	return t.mode == "edit" && t.editor.Value() != t.saved
+}
+
+// label names the tab in the tab bar, marking unsaved edits
+func (t tab) label() string {
+	if t.dirty() {
+		return t.name + " ●"
+	}
+	return t.name
+}
+
+// newEditor returns a textarea for writing a note, sized to the screen
+func (m Model) newEditor() textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = "Enter note content..."
+	ta.ShowLineNumbers = false
+	ta.SetWidth(m.editorWidth())
+	ta.SetHeight(editorHeight)
+	return ta
+}
+
+// editorWidth returns the width of the textarea, which is never wider than StandardWidth
+func (m Model) editorWidth() int {
+	if m.width <= 0 {
+		return StandardWidth - StandardTextInputPadding
+	}
+	return min(m.width-mainMarginWidth, StandardWidth) - StandardTextInputPadding
+}
+
+// showsTabBar reports whether the current screen shows the tab bar
+func (m Model) showsTabBar() bool {
+	return (m.state == "view" || m.state == "edit") && m.activeTab >= 0
+}
+
+// tabIndex returns the index of the tab showing a note, or -1
+func (m Model) tabIndex(path string) int {
+	for i, t := range m.tabs {
+		if t.path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// openTab shows the note under the cursor in its tab, opening one if
+// needed. With mode "edit" the note is edited in its tab.
+func (m *Model) openTab(mode string) {
+	n := m.notes[m.cursor]
+	i := m.tabIndex(n.Path)
+
+	if mode == "edit" && (i < 0 || m.tabs[i].mode != "edit") {
+		content, err := m.notesApp.GetContent(n)
+		if err != nil {
+			m.fail(err)
+			return
+		}
+		editor := m.newEditor()
+		editor.SetValue(content)
+		t := tab{path: n.Path, name: n.Name, mode: "edit", editor: editor, saved: content}
+		if i < 0 {
+			m.tabs = append(m.tabs, t)
+			i = len(m.tabs) - 1
+		} else {
+			t.viewOffset = m.tabs[i].viewOffset
+			m.tabs[i] = t
+		}
+	}
+	if i < 0 {
+		m.tabs = append(m.tabs, tab{path: n.Path, name: n.Name, mode: "view"})
+		i = len(m.tabs) - 1
+	}
+	m.showTab(i)
+}
+
+// showTab switches to a tab, showing its note where it was left
+func (m *Model) showTab(i int) {
+	t := m.tabs[i]
+	if !m.selectTabNote(t.path) && t.mode == "view" {
+		// Nothing is lost by closing a tab that only shows a note
+		m.closeTab(i)
+		m.fail(fmt.Errorf("%w: %s", storage.ErrNotFound, t.name))
+		return
+	}
+
+	m.activeTab = i
+	m.state = t.mode
+	m.viewOffset = t.viewOffset
+	if t.mode == "edit" {
+		m.textarea = t.editor
+		m.textarea.Focus()
+		m.editorScroll = t.editorScroll
+	}
+}
+
+// selectTabNote moves the cursor to the note of a tab, clearing the tag
+// filter if it hides the note, and reports whether the note was found
+func (m *Model) selectTabNote(path string) bool {
+	if len(m.notes) > 0 && m.notes[m.cursor].Path == path {
+		return true
+	}
+	if m.tagFilter != "" {
+		m.tagFilter = ""
+		m.refreshNotes()
+	}
+	for i, n := range m.notes {
+		if n.Path == path {
+			m.cursor = i
+			m.ensureCursorVisible()
+			return true
+		}
+	}
+	return false
+}
+
+// stashTab copies the scroll position and editor of the shown tab into it,
+// so they are kept while other tabs and screens are shown
+func (m *Model) stashTab() {
+	if !m.showsTabBar() || m.activeTab >= len(m.tabs) {
+		return
+	}
+	t := &m.tabs[m.activeTab]
+	if t.mode != m.state {
+		return
+	}
+	t.viewOffset = m.viewOffset
+	if t.mode == "edit" {
+		t.editor = m.textarea
+		t.editorScroll = m.editorScroll
+	}
+}
+
+// switchTab shows the tab delta places after the active one, wrapping around
+func (m *Model) switchTab(delta int) {
+	if len(m.tabs) == 0 {
+		return
+	}
+	m.showTab(((m.activeTab+delta)%len(m.tabs) + len(m.tabs)) % len(m.tabs))
+}
+
+// returnToTabs shows the last active tab from the note list
+func (m *Model) returnToTabs() {
+	if m.activeTab >= 0 && m.activeTab < len(m.tabs) {
+		m.showTab(m.activeTab)
+	}
+}
+
+// closeActiveTab closes the shown tab, asking first if it has unsaved edits
+func (m *Model) closeActiveTab() {
+	if m.tabs[m.activeTab].dirty() {
+		m.state = "confirm_close"
+		return
+	}
+	m.closeTab(m.activeTab)
+}
+
+// closeTab closes a tab, dropping unsaved edits, and shows the tab beside
+// it or the list when it was the last one
+func (m *Model) closeTab(i int) {
+	tabs := append([]tab{}, m.tabs[:i]...)
+	m.tabs = append(tabs, m.tabs[i+1:]...)
+	if len(m.tabs) == 0 {
+		m.activeTab = -1
+		m.state = "list"
+		return
+	}
+	m.showTab(min(i, len(m.tabs)-1))
+}
+
+// retargetTab points the tab of a renamed note at its new path and name
+func (m *Model) retargetTab(oldPath, newPath, newName string) {
+	if i := m.tabIndex(oldPath); i >= 0 {
+		m.tabs[i].path = newPath
+		m.tabs[i].name = newName
+	}
+}
+
+// syncTabs closes the tabs of notes that no longer exist, unless they have
+// unsaved edits, which stay open so they can still be copied elsewhere
+func (m *Model) syncTabs() {
+	if len(m.tabs) == 0 {
+		return
+	}
+	exists := make(map[string]bool)
+	for _, n := range m.notesApp.ListAllNotes() {
+		exists[n.Path] = true
+	}
+
+	var active string
+	if m.activeTab >= 0 && m.activeTab < len(m.tabs) {
+		active = m.tabs[m.activeTab].path
+	}
+	tabs := make([]tab, 0, len(m.tabs))
+	m.activeTab = -1
+	for _, t := range m.tabs {
+		if !exists[t.path] && !t.dirty() {
+			continue
+		}
+		if t.path == active {
+			m.activeTab = len(tabs)
+		}
+		tabs = append(tabs, t)
+	}
+	m.tabs = tabs
+	if m.activeTab < 0 && len(m.tabs) > 0 {
+		m.activeTab = len(m.tabs) - 1
+	}
+}
+
+// unsavedTabs returns the names of the notes with unsaved edits
+func (m Model) unsavedTabs() []string {
+	var names []string
+	for _, t := range m.tabs {
+		if t.dirty() {
+			names = append(names, t.name)
+		}
+	}
+	return names
+}
+
+// updateConfirmClose handles keys while closing a tab with unsaved edits is confirmed
+func (m Model) updateConfirmClose(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case m.matches(msg, actionSubmit):
+		m.closeTab(m.activeTab)
+		m.saveDrafts()
+	case m.matches(msg, actionBack):
+		m.showTab(m.activeTab)
+	}
+	return m, nil
+}
+
+// updateConfirmQuit handles keys while quitting with unsaved edits is confirmed
+func (m Model) updateConfirmQuit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case m.matches(msg, actionSubmit):
+		return m, m.quit()
+	case m.matches(msg, actionBack):
+		m.state = "list"
+	}
+	return m, nil
+}
+
+// tabWindow returns the first tab shown in the tab bar, which leaves out
+// tabs on the left until the active one fits
+func (m Model) tabWindow() int {
+	if m.width <= 0 || m.activeTab < 0 {
+		return 0
+	}
+	width := m.width - mainMarginWidth
+	start, used := 0, 0
+	for i := 0; i <= m.activeTab && i < len(m.tabs); i++ {
+		used += tabStyle.GetHorizontalPadding() + ansi.StringWidth(m.tabs[i].label()) + tabGap
+		for used > width && start < i {
+			used -= tabStyle.GetHorizontalPadding() + ansi.StringWidth(m.tabs[start].label()) + tabGap
+			start++
+		}
+	}
+	return start
+}
+
+// renderTabBar renders the open tabs above the view and edit screens
+func (m Model) renderTabBar() string {
+	var tabs []string
+	for i := m.tabWindow(); i < len(m.tabs); i++ {
+		style := tabStyle
+		if i == m.activeTab {
+			style = activeTabStyle
+		}
+		tabs = append(tabs, style.Render(m.tabs[i].label()))
+	}
+
+	bar := strings.Join(tabs, strings.Repeat(" ", tabGap))
+	if m.width > 0 {
+		bar = ansi.Truncate(bar, m.width-mainMarginWidth, "…")
+	}
+	return bar + strings.Repeat("\n", tabBarHeight)
+}
+
+// tabAt returns the index of the tab shown at column x of the tab bar
+func (m Model) tabAt(x int) (int, bool) {
+	col := x - mainMarginLeft
+	for i := m.tabWindow(); i < len(m.tabs) && col >= 0; i++ {
+		width := lipgloss.Width(tabStyle.Render(m.tabs[i].label()))
+		if col < width {
+			return i, true
+		}
+		col -= width + tabGap
+	}
+	return 0, false
+}
+
+// renderConfirmClose renders the screen confirming closing a tab with unsaved edits
+func (m Model) renderConfirmClose() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Close Tab") + "\n\n")
+	s.WriteString(fmt.Sprintf("'%s' has unsaved changes. Close it and lose them?\n", m.tabs[m.activeTab].name))
+	return s.String()
+}
+
+// renderConfirmQuit renders the screen confirming quitting with unsaved edits
+func (m Model) renderConfirmQuit() string {
+	names := m.unsavedTabs()
+
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Quit") + "\n\n")
+	s.WriteString(fmt.Sprintf("%d %s unsaved changes that will be lost:\n\n", len(names), plural(len(names), "tab has", "tabs have")))
+	for i, name := range names {
+		if i == maxListedTargets {
+			s.WriteString(helpStyle.Render(fmt.Sprintf("  and %d more", len(names)-i)) + "\n")
+			break
+		}
+		s.WriteString("  " + name + "\n")
+	}
+	s.WriteString(fmt.Sprintf("\nQuit anyway? Press '%s' again to quit at once.\n", m.describe(actionQuit)))
+	return s.String()
+}
@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+	"notes-app/internal/common"
+	"notes-app/internal/config"
+)
+
+// Theme holds the colors of the interface. Colors are hex values or ANSI
+// color numbers; an empty color leaves the terminal's own color.
+type Theme struct {
+	Name               string `toml:"-"`
+	Base               string `toml:"base"`
+	Text               string `toml:"text"`
+	Muted              string `toml:"muted"`
+	Border             string `toml:"border"`
+	Title              string `toml:"title"`
+	TitleBackground    string `toml:"title_background"`
+	Selected           string `toml:"selected"`
+	SelectedBackground string `toml:"selected_background"`
+	Tag                string `toml:"tag"`
+	Error              string `toml:"error"`
+}
+
+// Theme names with a special meaning
+const (
+	themeAuto    = "auto"
+	themeNoColor = "no-color"
+)
+
+// builtinThemes are the themes that need no theme file
+var builtinThemes = map[string]Theme{
+	"dark": {
+		Text:               "#FFFFFF",
+		Muted:              "#666666",
+		Border:             "#666666",
+		Title:              "#FAFAFA",
+		TitleBackground:    "#7B2CBF",
+		Selected:           "#000000",
+		SelectedBackground: "#9D4EDD",
+		Tag:                "#5af78e",
+		Error:              "#FF0000",
+	},
+	"light": {
+		Text:               "#1A1A1A",
+		Muted:              "#6B6B6B",
+		Border:             "#9A9A9A",
+		Title:              "#FFFFFF",
+		TitleBackground:    "#6A1B9A",
+		Selected:           "#FFFFFF",
+		SelectedBackground: "#7B2CBF",
+		Tag:                "#1B7F3B",
+		Error:              "#C62828",
+	},
+	"high-contrast": {
+		Text:               "15",
+		Muted:              "11",
+		Border:             "15",
+		Title:              "0",
+		TitleBackground:    "11",
+		Selected:           "0",
+		SelectedBackground: "14",
+		Tag:                "10",
+		Error:              "9",
+	},
+	// Selection is shown in reverse video when there are no colors
+	themeNoColor: {},
+}
+
+// themesDir returns the directory holding user theme files
+func themesDir() string {
+	return filepath.Join(common.GetConfigDir(), "themes")
+}
+
+// ThemeNames returns the names of the built-in and user themes
+func ThemeNames() []string {
+	names := []string{"dark", "light", "high-contrast"}
+
+	files, _ := filepath.Glob(filepath.Join(themesDir(), "*.toml"))
+	var user []string
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".toml")
+		if _, ok := builtinThemes[name]; !ok {
+			user = append(user, name)
+		}
+	}
+	sort.Strings(user)
+
+	return append(names, user...)
+}
+
+// LoadTheme returns the theme with the given name. "auto" picks dark or light
+// from the terminal background, and NO_COLOR turns colors off whatever the name.
+func LoadTheme(name string) (Theme, error) {
+	if os.Getenv("NO_COLOR") != "" {
+		name = themeNoColor
+	}
+	if name == themeAuto {
+		name = "light"
+		if lipgloss.HasDarkBackground() {
+			name = "dark"
+		}
+	}
+
+	if theme, ok := builtinThemes[name]; ok {
+		theme.Name = name
+		return theme, nil
+	}
+	return loadThemeFile(name)
+}
+
+// loadThemeFile reads a user theme. Colors it leaves out come from its base
+// theme, which defaults to dark.
+func loadThemeFile(name string) (Theme, error) {
+	path := filepath.Join(themesDir(), name+".toml")
+
+	var theme Theme
+	meta, err := toml.DecodeFile(path, &theme)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Theme{}, fmt.Errorf("theme '%s' is not available (available: %s, auto)", name, strings.Join(ThemeNames(), ", "))
+		}
+		return Theme{}, fmt.Errorf("failed to parse theme file %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return Theme{}, fmt.Errorf("unknown setting '%s' in theme file %s", undecoded[0], path)
+	}
+
+	if theme.Base == "" {
+		theme.Base = "dark"
+	}
+	base, ok := builtinThemes[theme.Base]
+	if !ok {
+		return Theme{}, fmt.Errorf("theme file %s: base '%s' is not a built-in theme", path, theme.Base)
+	}
+	theme.fillFrom(base)
+	theme.Name = name
+	return theme, nil
+}
+
+// fillFrom copies the colors that are not set from another theme
+func (t *Theme) fillFrom(base Theme) {
+	fields := []struct {
+		dst *string
+		src string
+	}{
+		{&t.Text, base.Text},
+		{&t.Muted, base.Muted},
+		{&t.Border, base.Border},
+		{&t.Title, base.Title},
+		{&t.TitleBackground, base.TitleBackground},
+		{&t.Selected, base.Selected},
+		{&t.SelectedBackground, base.SelectedBackground},
+		{&t.Tag, base.Tag},
+		{&t.Error, base.Error},
+	}
+	for _, f := range fields {
+		if *f.dst == "" {
+			*f.dst = f.src
+		}
+	}
+}
+
+// ValidateTheme reports an unknown theme name or a broken theme file
+func ValidateTheme(cfg *config.Config) []string {
+	if cfg.Theme == themeAuto {
+		return nil
+	}
+	if _, ok := builtinThemes[cfg.Theme]; ok {
+		return nil
+	}
+	if _, err := loadThemeFile(cfg.Theme); err != nil {
+		return []string{fmt.Sprintf("theme: %v", err)}
+	}
+	return nil
+}
+
+// color converts a theme color, where an empty string means no color
+func color(c string) lipgloss.TerminalColor {
+	if c == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(c)
+}
+
+// nextTheme switches to the next available theme and remembers it
+func (m *Model) nextTheme() {
+	if os.Getenv("NO_COLOR") != "" {
+		m.fail(fmt.Errorf("colors are turned off by NO_COLOR"))
+		return
+	}
+
+	names := ThemeNames()
+	start := -1
+	for i, name := range names {
+		if name == m.theme.Name {
+			start = i
+		}
+	}
+
+	// Broken theme files are skipped so they cannot block switching
+	var theme Theme
+	var err error
+	for i := 1; i <= len(names); i++ {
+		theme, err = LoadTheme(names[(start+i)%len(names)])
+		if err == nil {
+			break
+		}
+		log.Warn("skipping theme", "err", err)
+	}
+	if err != nil {
+		m.fail(err)
+		return
+	}
+	m.theme = theme
+	applyTheme(theme)
+	m.info("Theme: %s", theme.Name)
+
+	m.session.Theme = theme.Name
+	if err := m.session.Save(); err != nil {
+		m.fail(err)
+	}
+}
@@ -0,0 +1,283 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"notes-app/internal/app"
+)
+
+// vaultApp returns the opened application for a vault, opening it on first use.
+// Opened vaults stay open so switching back and searching are fast.
+func (m *Model) vaultApp(name string) (*app.NotesApp, error) {
+	if a, ok := m.vaultApps[name]; ok {
+		return a, nil
+	}
+
+	vault, err := m.config.Vault(name)
+	if err != nil {
+		return nil, err
+	}
+	store, err := vault.NewStorage()
+	if err != nil {
+		return nil, err
+	}
+	a, err := app.Open(store, vault.DefaultExtension)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vault '%s': %w", name, err)
+	}
+
+	m.vaultApps[name] = a
+	return a, nil
+}
+
+// switchVault makes another vault the current one and reloads the note list
+func (m *Model) switchVault(name string) error {
+	switching := name != m.config.CurrentVault().Name
+	if unsaved := m.unsavedTabs(); len(unsaved) > 0 && switching {
+		return fmt.Errorf("save or close the tabs with unsaved changes first: %s", strings.Join(unsaved, ", "))
+	}
+	a, err := m.vaultApp(name)
+	if err != nil {
+		return err
+	}
+	if a != m.notesApp {
+		// The vault may have changed on disk since it was last shown
+		if err := a.RefreshIndex(); err != nil {
+			return err
+		}
+	}
+	if err := m.config.UseVault(name); err != nil {
+		return err
+	}
+
+	log.Info("switched vault", "vault", name, "path", a.RootPath())
+	m.notesApp = a
+	m.cursor = 0
+	m.offset = 0
+	m.problemCursor = 0
+	m.selected = make(map[int]struct{})
+	m.tagFilter = ""
+	if switching {
+		// Tabs show notes of the vault they were opened in
+		m.tabs = nil
+		m.activeTab = -1
+	}
+	m.refreshNotes()
+	return nil
+}
+
+// closeVaults closes every opened vault
+func (m Model) closeVaults() {
+	for name, a := range m.vaultApps {
+		if err := a.Close(); err != nil {
+			log.Warn("failed to close vault", "vault", name, "err", err)
+		}
+	}
+}
+
+// updateVaults handles keys on the vault switcher
+func (m Model) updateVaults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	names := m.config.VaultNames()
+
+	switch {
+	case m.matches(msg, actionBack):
+		m.state = "list"
+	case m.matches(msg, actionUp):
+		if m.vaultCursor > 0 {
+			m.vaultCursor--
+		}
+	case m.matches(msg, actionDown):
+		if m.vaultCursor < len(names)-1 {
+			m.vaultCursor++
+		}
+	case m.matches(msg, actionOpen):
+		m.openVault()
+	}
+
+	return m, nil
+}
+
+// openVault switches to the vault under the cursor and returns to the list
+func (m *Model) openVault() {
+	name := m.config.VaultNames()[m.vaultCursor]
+	if err := m.switchVault(name); err != nil {
+		m.fail(err)
+		return
+	}
+	m.info("Opened vault '%s'", name)
+	m.state = "list"
+}
+
+// vaultsHeader renders the lines above the vault list
+func (m Model) vaultsHeader() string {
+	return titleStyle.Render("Vaults") + "\n\n"
+}
+
+// renderVaults lists the configured vaults
+func (m Model) renderVaults() string {
+	var s strings.Builder
+	current := m.config.CurrentVault().Name
+
+	s.WriteString(m.vaultsHeader())
+
+	names := m.config.VaultNames()
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+
+	var list strings.Builder
+	for i, name := range names {
+		cursor := " "
+		if i == m.vaultCursor {
+			cursor = ">"
+		}
+
+		vault, err := m.config.Vault(name)
+		if err != nil {
+			continue
+		}
+		line := fmt.Sprintf("%s %-*s  %s", cursor, width, name, vault.Path)
+		if vault.Backend != "" && vault.Backend != "filesystem" {
+			line += fmt.Sprintf(" (%s)", vault.Backend)
+		}
+		if name == current {
+			line += " ✓"
+		}
+
+		if i == m.vaultCursor {
+			list.WriteString(selectedNoteStyle.Render(line))
+		} else {
+			list.WriteString(noteStyle.Render(line))
+		}
+		list.WriteString("\n")
+	}
+	s.WriteString(listStyle.Render(strings.TrimSuffix(list.String(), "\n")))
+
+	s.WriteString("\n" + helpStyle.Render(fmt.Sprintf("Add vaults under [vaults] in %s", m.config.Path())))
+
+	return s.String()
+}
+
+// startSearch opens the search screen, opening every vault so they can all be searched
+func (m *Model) startSearch() {
+	for _, name := range m.config.VaultNames() {
+		if _, err := m.vaultApp(name); err != nil {
+			log.Warn("vault not searched", "vault", name, "err", err)
+		}
+	}
+
+	m.state = "search"
+	m.searchCursor = 0
+	m.searchInput.Reset()
+	m.searchInput.Focus()
+	m.searchResults = nil
+}
+
+// searchVaultNames returns the vaults to search, the current one first
+func (m Model) searchVaultNames() []string {
+	current := m.config.CurrentVault().Name
+	names := []string{current}
+	for _, name := range m.config.VaultNames() {
+		if name != current {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// updateSearch handles keys on the search screen
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case m.matches(msg, actionBack):
+		m.state = "list"
+		m.searchInput.Blur()
+		return m, nil
+	case m.matches(msg, actionUp):
+		if m.searchCursor > 0 {
+			m.searchCursor--
+		}
+		return m, nil
+	case m.matches(msg, actionDown):
+		if m.searchCursor < len(m.searchResults)-1 {
+			m.searchCursor++
+		}
+		return m, nil
+	case m.matches(msg, actionSubmit):
+		m.openSearchMatch()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	m.searchResults = app.SearchVaults(m.searchVaultNames(), m.vaultApps, m.searchInput.Value())
+	m.searchCursor = 0
+	return m, cmd
+}
+
+// openSearchMatch shows the match under the cursor, switching vault if needed
+func (m *Model) openSearchMatch() {
+	if len(m.searchResults) == 0 {
+		return
+	}
+	match := m.searchResults[m.searchCursor]
+	if match.Vault != m.config.CurrentVault().Name {
+		if err := m.switchVault(match.Vault); err != nil {
+			m.fail(err)
+			return
+		}
+	}
+	m.selectByPath(match.Note.Path)
+	m.searchInput.Blur()
+	m.openNote()
+}
+
+// searchHeader renders the title and the query input above the matches
+func (m Model) searchHeader() string {
+	return titleStyle.Render("Search all vaults") + "\n\n" + inputStyle.Render(m.searchInput.View()) + "\n"
+}
+
+// searchWindow returns the range of matches shown, which keeps the cursor visible
+func (m Model) searchWindow() (start, end int) {
+	rows := m.visibleRows()
+	if m.searchCursor >= rows {
+		start = m.searchCursor - rows + 1
+	}
+	return start, min(start+rows, len(m.searchResults))
+}
+
+// renderSearch shows the search input and matches from every vault
+func (m Model) renderSearch() string {
+	var s strings.Builder
+
+	s.WriteString(m.searchHeader())
+
+	if m.searchInput.Value() != "" && len(m.searchResults) == 0 {
+		s.WriteString(listStyle.Render("No matches."))
+	} else if len(m.searchResults) > 0 {
+		start, end := m.searchWindow()
+
+		var list strings.Builder
+		for i := start; i < end; i++ {
+			match := m.searchResults[i]
+			cursor := " "
+			if i == m.searchCursor {
+				cursor = ">"
+			}
+			line := fmt.Sprintf("%s %s %s", cursor, match.Note.DisplayTitle(), tagStyle.Render("@"+match.Vault))
+			if i == m.searchCursor {
+				list.WriteString(selectedNoteStyle.Render(line))
+			} else {
+				list.WriteString(noteStyle.Render(line))
+			}
+			list.WriteString("\n")
+		}
+		s.WriteString(listStyle.Render(strings.TrimSuffix(list.String(), "\n")))
+	}
+
+	s.WriteString("\n" + helpStyle.Render("Start the query with # to search tags"))
+
+	return s.String()
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"notes-app/internal/note"
+)
+
+// viewChromeHeight covers the margins, tab bar, note header, scroll position, help bar and status bar
+const viewChromeHeight = 8 + tabBarHeight
+
+// viewRows returns how many lines of a note fit in the view screen
+func (m Model) viewRows() int {
+	if m.height <= 0 {
+		return defaultListRows
+	}
+	return max(1, m.height-viewChromeHeight)
+}
+
+// noteLines returns the content of the selected note wrapped to the screen width
+func (m Model) noteLines() ([]string, error) {
+	content, err := m.notesApp.GetContent(m.notes[m.cursor])
+	if err != nil {
+		return nil, err
+	}
+	if m.width > 0 {
+		content = lipgloss.NewStyle().Width(m.width - mainMarginWidth).Render(content)
+	}
+	return strings.Split(content, "\n"), nil
+}
+
+// scrollView scrolls the view screen by delta lines, clamped to the note
+func (m *Model) scrollView(delta int) {
+	lines, err := m.noteLines()
+	if err != nil {
+		return
+	}
+	maxOffset := max(0, len(lines)-m.viewRows())
+	m.viewOffset = max(0, min(maxOffset, m.viewOffset+delta))
+}
+
+// noteTitle renders the title of a note in the view screen
+func noteTitle(n *note.Note) string {
+	return titleStyle.UnsetMarginBottom().Render(n.DisplayTitle())
+}
+
+// renderNote renders the selected note from viewOffset on
+func (m Model) renderNote() string {
+	var s strings.Builder
+	n := m.notes[m.cursor]
+
+	s.WriteString(noteTitle(n))
+	if len(n.Metadata.Tags) > 0 {
+		s.WriteString(" " + tagStyle.Render(fmt.Sprintf("[%s]", strings.Join(n.Metadata.Tags, ", "))))
+	}
+	s.WriteString("\n")
+	s.WriteString(helpStyle.Render(fmt.Sprintf("Created %s · Modified %s",
+		n.Metadata.Created.Format(m.config.DateTimeFormat),
+		n.ModTime.Format(m.config.DateTimeFormat))))
+	s.WriteString("\n\n")
+
+	lines, err := m.noteLines()
+	if err != nil {
+		s.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", err)) + "\n")
+		return s.String()
+	}
+
+	rows := m.viewRows()
+	start := min(m.viewOffset, max(0, len(lines)-rows))
+	end := min(start+rows, len(lines))
+	s.WriteString(strings.Join(lines[start:end], "\n") + "\n")
+	if len(lines) > rows {
+		s.WriteString(helpStyle.Render(fmt.Sprintf("Lines %d-%d of %d", start+1, end, len(lines))))
+	}
+	return s.String()
+}
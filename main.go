@@ -1,28 +1,94 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"notes-app/internal/app"
 	"notes-app/internal/common"
+	"notes-app/internal/config"
+	"notes-app/internal/doctor"
+	"notes-app/internal/logger"
+	"notes-app/internal/note"
+	"notes-app/internal/storage"
 	"notes-app/internal/ui"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 func main() {
-	notesPath := os.Getenv("NOTES_PATH")
-	if notesPath == "" {
-		notesPath = common.GetDefaultNotesPath()
+	vaultName := flag.String("vault", "", "name of the vault to open, see the vaults command")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: notes-app [--vault name] [command]\n\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Commands: config, vaults, doctor, migrate-meta, migrate-storage\n\n")
+		flag.PrintDefaults()
 	}
+	flag.Parse()
+	args := flag.Args()
 
-	notesApp := app.NewNotesApp(notesPath)
-	if err := notesApp.Initialize(); err != nil {
+	cfg, err := config.Load(config.DefaultPath(), ui.ValidateKeybindings, ui.ValidateTheme, ui.ValidateSplitRatio)
+	if err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if *vaultName != "" {
+		if err := cfg.UseVault(*vaultName); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := logger.Init(logger.Options{Dir: logDir(), Levels: cfg.LogLevel}); err != nil {
+		fmt.Printf("Warning: logging disabled: %v\n", err)
+	}
+	defer logger.Close()
+
+	// These commands work without opening the vault
+	if len(args) > 0 {
+		switch args[0] {
+		case "config":
+			printConfig(cfg)
+			return
+		case "vaults":
+			printVaults(cfg)
+			return
+		}
+	}
+
+	store, err := cfg.NewStorage()
+	if err != nil {
 		fmt.Printf("Error initializing app: %v\n", err)
 		os.Exit(1)
 	}
 
+	// The doctor looks at the vault as it is on disk, so it runs before
+	// opening the vault, which migrates and saves outdated metadata
+	if len(args) > 0 && args[0] == "doctor" {
+		if err := runDoctor(store, cfg, args[1:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	notesApp, err := app.Open(store, cfg.DefaultExtension)
+	if err != nil {
+		fmt.Printf("Error initializing app: %v\n", err)
+		os.Exit(1)
+	}
+	defer notesApp.Close()
+
+	if len(args) > 0 {
+		if err := runCommand(notesApp, cfg, args[0], args[1:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	p := tea.NewProgram(
-		ui.NewModel(notesApp),
+		ui.NewModel(notesApp, cfg),
 		// tea.WithAltScreen(),
 		tea.WithMouseAllMotion(),
 	)
@@ -32,3 +98,170 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// logDir returns the directory holding the log files
+func logDir() string {
+	return filepath.Join(common.GetStateDir(), "logs")
+}
+
+// printConfig prints the effective configuration and where it came from
+func printConfig(cfg *config.Config) {
+	if cfg.Exists() {
+		fmt.Printf("# Loaded from %s\n", cfg.Path())
+	} else {
+		fmt.Printf("# %s does not exist, showing defaults\n", cfg.Path())
+	}
+	fmt.Print(cfg.String())
+	fmt.Printf("# Logging to %s\n", logger.Path(logDir()))
+}
+
+// printVaults lists the configured vaults, marking the one in use
+func printVaults(cfg *config.Config) {
+	current := cfg.CurrentVault().Name
+	for _, name := range cfg.VaultNames() {
+		vault, err := cfg.Vault(name)
+		if err != nil {
+			continue
+		}
+		marker := " "
+		if name == current {
+			marker = "*"
+		}
+		fmt.Printf("%s %-12s %-10s %s\n", marker, name, vault.Backend, vault.Path)
+	}
+}
+
+// runCommand runs a non-interactive command given on the command line
+func runCommand(notesApp *app.NotesApp, cfg *config.Config, command string, args []string) error {
+	switch command {
+	case "migrate-meta":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: notes-app migrate-meta <%s|%s>", note.FormatSidecar, note.FormatFrontMatter)
+		}
+		format, err := note.ParseMetadataFormat(args[0])
+		if err != nil {
+			return err
+		}
+		converted, err := notesApp.MigrateMetadataFormat(format)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Converted %d notes to %s metadata\n", converted, format)
+		return nil
+	case "migrate-storage":
+		return migrateStorage(notesApp, cfg, args)
+	default:
+		return fmt.Errorf("unknown command '%s'", command)
+	}
+}
+
+// migrateStorage copies the current vault into another storage backend.
+// The target defaults to the configured vault path or SQLite file.
+func migrateStorage(notesApp *app.NotesApp, cfg *config.Config, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: notes-app migrate-storage <%s> [target path]", strings.Join(storage.Backends, "|"))
+	}
+
+	backend := args[0]
+	if backend == cfg.Backend && len(args) == 1 {
+		return fmt.Errorf("the vault already uses the %s backend, give a target path", backend)
+	}
+
+	vaultPath, sqlitePath := cfg.VaultPath, cfg.SQLitePath
+	if len(args) == 2 {
+		vaultPath, sqlitePath = args[1], args[1]
+	}
+
+	target, err := storage.New(backend, vaultPath, sqlitePath)
+	if err != nil {
+		return err
+	}
+	if err := target.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize target: %w", err)
+	}
+
+	copied, skipped, err := notesApp.CopyTo(target)
+	fmt.Printf("Copied %d notes to %s storage at %s\n", copied, backend, target.GetRootPath())
+	if err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("Skipped %d notes:\n", len(skipped))
+		for _, p := range skipped {
+			fmt.Printf("  %s (%s): %v\n", p.File, p.Kind, p.Err)
+		}
+		return fmt.Errorf("%d notes were not copied", len(skipped))
+	}
+	if backend != cfg.Backend {
+		fmt.Printf("Set backend = \"%s\" in %s to use it\n", backend, cfg.Path())
+	}
+	return nil
+}
+
+// runDoctor checks the vault for consistency problems and prints a report.
+// With --fix the safe problems are fixed; --dry-run only shows what would change.
+func runDoctor(store storage.Storage, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fix := flags.Bool("fix", false, "fix the problems that can be fixed safely")
+	dryRun := flags.Bool("dry-run", false, "show what --fix would change without changing anything")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("usage: notes-app doctor [--fix] [--dry-run]")
+	}
+
+	fs, ok := store.(*storage.FileSystemStorage)
+	if !ok {
+		return fmt.Errorf("the doctor only checks filesystem vaults")
+	}
+	if _, err := os.Stat(fs.GetRootPath()); err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+	fs.SetDefaultExtension(cfg.DefaultExtension)
+	if err := fs.Initialize(); err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+
+	opts := doctor.Options{Fix: *fix, DryRun: *dryRun}
+	report, err := doctor.Run(fs, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(report.Issues) == 0 {
+		fmt.Printf("No problems found in %s\n", report.Root)
+		return nil
+	}
+
+	for _, issue := range report.Issues {
+		path, err := filepath.Rel(report.Root, issue.Path)
+		if err != nil {
+			path = issue.Path
+		}
+
+		status := ""
+		switch {
+		case issue.Fixed:
+			status = " (fixed)"
+		case issue.FixErr != nil:
+			status = fmt.Sprintf(" (fix failed: %v)", issue.FixErr)
+		case issue.Fixable && opts.DryRun:
+			status = " (would fix)"
+		case issue.Fixable:
+			status = " (fixable)"
+		}
+		fmt.Printf("%-7s %-16s %s: %s%s\n", issue.Severity, issue.Check, path, issue.Message, status)
+	}
+
+	fmt.Printf("\nErrors: %d, warnings: %d, notices: %d", report.Count(doctor.Error), report.Count(doctor.Warning), report.Count(doctor.Info))
+	switch {
+	case report.Fixed() > 0:
+		fmt.Printf(", fixed: %d\n", report.Fixed())
+	case report.Fixable() > 0 && (!opts.Fix || opts.DryRun):
+		fmt.Printf(", fixable: %d (run with --fix)\n", report.Fixable())
+	default:
+		fmt.Println()
+	}
+	return nil
+}